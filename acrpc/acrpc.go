@@ -16,9 +16,18 @@ import (
 )
 
 type APC struct {
+	// host:port, or tcp://host:port, tcp4://, tcp6://, unix:///path/to/sock
 	Addr    string
 	MsgId   uint32
 	Timeout time.Duration
+	// optional, for local address binding, keepalive, etc
+	Dialer *net.Dialer
+	// optional, if set, Addr is ignored
+	Resolver Resolver
+	// negotiate version + features with the peer on first contact
+	Negotiate bool
+	// features to offer (FEATURE_*)
+	Features uint32
 	// Secret
 }
 
@@ -33,33 +42,56 @@ type acProto struct {
 }
 
 const (
-	PHVERSION      = 0x41433032
+	PHVERSION      = 0x41433032 // AC02
+	PHVERSION3     = 0x41433033 // AC03 - negotiated
 	FLAG_ISREPLY   = 0x1
 	FLAG_WANTREPLY = 0x2
 	FLAG_ISERROR   = 0x4
 	FLAG_DATA_ENCR = 0x8  // not supported
 	FLAG_CONT_ENCR = 0x10 // ''
+	FLAG_STREAM    = 0x20 // part of a streaming session
+	FLAG_EOS       = 0x40 // end of stream
+	FLAG_HELLO     = 0x80 // version negotiation
 )
 
-type marshalable interface {
+// requests and replies (typically protobufs)
+type Marshalable interface {
 	Marshal() ([]byte, error)
 	Unmarshal([]byte) error
 }
 
+// Caller is implemented by APC, and by acrpctest.Mock for testing
+type Caller interface {
+	Call(fn uint32, req Marshalable, res Marshalable, content []byte) ([]byte, error)
+	Put(fn uint32, req Marshalable, res Marshalable, clen int32, r io.Reader) ([]byte, error)
+	Get(fn uint32, req Marshalable, res Marshalable, content []byte) (int, io.ReadCloser, error)
+}
+
+var _ Caller = (*APC)(nil)
+
 var dl = diag.Logger("acrpc")
 
-func (c *APC) sendRequest(conn net.Conn, fn uint32, req marshalable, clen int) error {
+func (c *APC) sendRequest(conn *rpcConn, fn uint32, req Marshalable, clen int) error {
+	return c.sendFrame(conn, FLAG_WANTREPLY, fn, req, clen)
+}
+
+func (c *APC) sendFrame(conn *rpcConn, flags uint32, fn uint32, req Marshalable, clen int) error {
 
 	// build request
-	data, err := req.Marshal()
-	if err != nil {
-		dl.Problem("cannot marshal AC/RPC: %v", err)
-		return err
+	var data []byte
+	var err error
+
+	if req != nil {
+		data, err = req.Marshal()
+		if err != nil {
+			dl.Problem("cannot marshal AC/RPC: %v", err)
+			return err
+		}
 	}
 
 	prot := &acProto{
-		Version:    PHVERSION,
-		Flags:      FLAG_WANTREPLY,
+		Version:    conn.version,
+		Flags:      flags,
 		Type:       fn,
 		MsgIdNo:    c.MsgId,
 		DataLen:    uint32(len(data)),
@@ -80,12 +112,16 @@ func (c *APC) sendRequest(conn net.Conn, fn uint32, req marshalable, clen int) e
 	return nil
 }
 
-func (c *APC) recvReply(conn net.Conn, res marshalable) (*acProto, error) {
+func (c *APC) recvReply(conn *rpcConn, res Marshalable) (*acProto, error) {
 
 	prot := &acProto{}
 
 	//   header, data(protobuf), content
 	err := binary.Read(conn, binary.BigEndian, prot)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// hung up on us
+		return nil, conn.botched(err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -93,11 +129,11 @@ func (c *APC) recvReply(conn net.Conn, res marshalable) (*acProto, error) {
 	dl.Debug("recvd prot %+v", prot)
 
 	// check prot
-	if prot.Version != PHVERSION {
-		return nil, errors.New("protocol botched: invalid AC/RPC version")
+	if !versionSupported(prot.Version) {
+		return nil, conn.botched(errors.New("protocol botched: invalid AC/RPC version"))
 	}
 	if prot.Flags&FLAG_ISREPLY == 0 {
-		return nil, errors.New("protocol botched: invalid response")
+		return nil, conn.botched(errors.New("protocol botched: invalid response"))
 	}
 	if prot.Flags&(FLAG_DATA_ENCR|FLAG_CONT_ENCR) != 0 {
 		return prot, errors.New("AC/RPC unsupported encryption algorithm")
@@ -123,16 +159,14 @@ func (c *APC) recvReply(conn net.Conn, res marshalable) (*acProto, error) {
 	return prot, nil
 }
 
-func (c *APC) Call(fn uint32, req marshalable, res marshalable, content []byte) ([]byte, error) {
+func (c *APC) Call(fn uint32, req Marshalable, res Marshalable, content []byte) ([]byte, error) {
 
 	// connect
-	dl.Debug("connect to %s", c.Addr)
-	conn, err := net.DialTimeout("tcp", c.Addr, c.Timeout)
+	conn, err := c.dial()
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
-	conn.SetDeadline(time.Now().Add(c.Timeout))
 
 	// send request
 	err = c.sendRequest(conn, fn, req, len(content))
@@ -162,16 +196,14 @@ func (c *APC) Call(fn uint32, req marshalable, res marshalable, content []byte)
 	return rcontent, nil
 }
 
-func (c *APC) Put(fn uint32, req marshalable, res marshalable, clen int32, r io.Reader) ([]byte, error) {
+func (c *APC) Put(fn uint32, req Marshalable, res Marshalable, clen int32, r io.Reader) ([]byte, error) {
 
 	// connect
-	dl.Debug("connect to %s", c.Addr)
-	conn, err := net.DialTimeout("tcp", c.Addr, c.Timeout)
+	conn, err := c.dial()
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
-	conn.SetDeadline(time.Now().Add(c.Timeout))
 
 	// send request
 	err = c.sendRequest(conn, fn, req, int(clen))
@@ -202,15 +234,13 @@ func (c *APC) Put(fn uint32, req marshalable, res marshalable, clen int32, r io.
 }
 
 // caller must close returned conn
-func (c *APC) Get(fn uint32, req marshalable, res marshalable, content []byte) (int, io.ReadCloser, error) {
+func (c *APC) Get(fn uint32, req Marshalable, res Marshalable, content []byte) (int, io.ReadCloser, error) {
 
 	// connect
-	dl.Debug("connect to %s", c.Addr)
-	conn, err := net.DialTimeout("tcp", c.Addr, c.Timeout)
+	conn, err := c.dial()
 	if err != nil {
 		return 0, nil, err
 	}
-	conn.SetDeadline(time.Now().Add(c.Timeout))
 
 	// send request
 	err = c.sendRequest(conn, fn, req, len(content))
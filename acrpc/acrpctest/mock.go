@@ -0,0 +1,171 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 12:10 (EDT)
+// Function: mock AC/RPC for unit tests
+
+/*
+in code:
+    type Thing struct {
+        rpc acrpc.Caller
+    }
+
+in tests:
+    m := acrpctest.New()
+    m.Reply(FN_GET, &pb.GetReply{...}, []byte("content"))
+    m.Error(FN_PUT, errors.New("boom"))
+    thing := &Thing{rpc: m}
+    ...
+    calls := m.Calls()
+*/
+
+// mock AC/RPC for unit tests
+package acrpctest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/jaw0/acgo/acrpc"
+)
+
+type Response struct {
+	Res     acrpc.Marshalable // marshaled, then unmarshaled into the caller's res
+	Content []byte
+	Err     error
+	Latency time.Duration
+}
+
+// Record is a recorded call
+type Record struct {
+	Method  string // Call, Put, Get
+	Fn      uint32
+	Req     []byte // marshaled request
+	Content []byte
+}
+
+type Mock struct {
+	// used for function numbers without a programmed response
+	Default *Response
+	// added to every call
+	Latency time.Duration
+
+	lock  sync.Mutex
+	resp  map[uint32]*Response
+	calls []Record
+}
+
+var _ acrpc.Caller = (*Mock)(nil)
+
+func New() *Mock {
+	return &Mock{resp: make(map[uint32]*Response)}
+}
+
+// Set programs the response for a function number
+func (m *Mock) Set(fn uint32, r *Response) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.resp == nil {
+		m.resp = make(map[uint32]*Response)
+	}
+	m.resp[fn] = r
+}
+
+// Reply programs a canned reply for a function number
+func (m *Mock) Reply(fn uint32, res acrpc.Marshalable, content []byte) {
+	m.Set(fn, &Response{Res: res, Content: content})
+}
+
+// Error programs an error for a function number
+func (m *Mock) Error(fn uint32, err error) {
+	m.Set(fn, &Response{Err: err})
+}
+
+// Calls returns the calls made so far
+func (m *Mock) Calls() []Record {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	calls := make([]Record, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// Reset clears recorded calls and programmed responses
+func (m *Mock) Reset() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.resp = make(map[uint32]*Response)
+	m.calls = nil
+}
+
+func (m *Mock) Call(fn uint32, req acrpc.Marshalable, res acrpc.Marshalable, content []byte) ([]byte, error) {
+	return m.do("Call", fn, req, res, content)
+}
+
+func (m *Mock) Put(fn uint32, req acrpc.Marshalable, res acrpc.Marshalable, clen int32, r io.Reader) ([]byte, error) {
+
+	content := make([]byte, clen)
+	_, err := io.ReadFull(r, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.do("Put", fn, req, res, content)
+}
+
+func (m *Mock) Get(fn uint32, req acrpc.Marshalable, res acrpc.Marshalable, content []byte) (int, io.ReadCloser, error) {
+
+	rcontent, err := m.do("Get", fn, req, res, content)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(rcontent), ioutil.NopCloser(bytes.NewReader(rcontent)), nil
+}
+
+func (m *Mock) do(method string, fn uint32, req acrpc.Marshalable, res acrpc.Marshalable, content []byte) ([]byte, error) {
+
+	rec := Record{Method: method, Fn: fn, Content: content}
+	if req != nil {
+		data, err := req.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		rec.Req = data
+	}
+
+	m.lock.Lock()
+	m.calls = append(m.calls, rec)
+	r := m.resp[fn]
+	if r == nil {
+		r = m.Default
+	}
+	latency := m.Latency
+	m.lock.Unlock()
+
+	if r == nil {
+		return nil, fmt.Errorf("acrpctest: no response for function %d", fn)
+	}
+
+	time.Sleep(latency + r.Latency)
+
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	if r.Res != nil && res != nil {
+		data, err := r.Res.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		err = res.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r.Content, nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 12:35 (EDT)
+// Function: test the mock
+
+package acrpctest
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type testMsg struct {
+	data []byte
+}
+
+func (m *testMsg) Marshal() ([]byte, error) { return m.data, nil }
+func (m *testMsg) Unmarshal(b []byte) error { m.data = append([]byte{}, b...); return nil }
+
+func TestMock(t *testing.T) {
+
+	m := New()
+	m.Reply(1, &testMsg{data: []byte("reply")}, []byte("content"))
+	m.Error(2, errors.New("boom"))
+
+	res := &testMsg{}
+	content, err := m.Call(1, &testMsg{data: []byte("req")}, res, nil)
+	if err != nil || string(res.data) != "reply" || string(content) != "content" {
+		t.Fatalf("call: got %q %q %v", res.data, content, err)
+	}
+
+	_, err = m.Put(2, &testMsg{}, res, 4, strings.NewReader("data"))
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("put: expected error, got %v", err)
+	}
+
+	n, r, err := m.Get(1, &testMsg{}, res, nil)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	buf, _ := ioutil.ReadAll(r)
+	r.Close()
+	if n != 7 || string(buf) != "content" {
+		t.Fatalf("get: got %d %q", n, buf)
+	}
+
+	if _, err = m.Call(3, &testMsg{}, res, nil); err == nil {
+		t.Fatalf("expected error for unprogrammed function")
+	}
+
+	calls := m.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls, got %d", len(calls))
+	}
+	if calls[0].Method != "Call" || string(calls[0].Req) != "req" {
+		t.Fatalf("bad record %+v", calls[0])
+	}
+	if calls[1].Method != "Put" || string(calls[1].Content) != "data" {
+		t.Fatalf("bad record %+v", calls[1])
+	}
+}
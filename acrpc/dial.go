@@ -0,0 +1,126 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 10:12 (EDT)
+// Function: connect to the server
+
+package acrpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// split url-ish address into network + address
+//
+//	host:port            => tcp, host:port
+//	tcp6://host:port     => tcp6, host:port
+//	unix:///path/to/sock => unix, /path/to/sock
+func parseAddr(addr string) (string, string, error) {
+
+	i := strings.Index(addr, "://")
+	if i == -1 {
+		return "tcp", addr, nil
+	}
+
+	network := addr[:i]
+	addr = addr[i+3:]
+
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return network, addr, nil
+	}
+
+	return "", "", fmt.Errorf("unsupported network '%s'", network)
+}
+
+// try each endpoint until one answers
+func (c *APC) dial() (*rpcConn, error) {
+
+	if c.Resolver == nil {
+		return c.dialAddr(c.Addr)
+	}
+
+	addrs, err := c.Resolver.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no endpoints available")
+	}
+
+	for _, addr := range addrs {
+		conn, e := c.dialAddr(addr)
+		if e == nil {
+			return conn, nil
+		}
+		dl.Debug("connect to %s failed: %v", addr, e)
+		err = e
+	}
+
+	return nil, err
+}
+
+func (c *APC) dialAddr(a string) (*rpcConn, error) {
+
+	conn, err := c.connect(a)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.Negotiate {
+		return &rpcConn{Conn: conn, version: PHVERSION}, nil
+	}
+
+	if p := getPeer(a); p != nil {
+		return &rpcConn{Conn: conn, addr: a, version: p.version, features: p.features & c.Features}, nil
+	}
+
+	p, err := c.hello(conn)
+	if err == errLegacyPeer {
+		// old peer, probably hung up on us. try again, the old way
+		dl.Debug("peer %s does not negotiate", a)
+		conn.Close()
+		setPeer(a, p)
+		conn, err = c.connect(a)
+	}
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+
+	setPeer(a, p)
+	return &rpcConn{Conn: conn, addr: a, version: p.version, features: p.features & c.Features}, nil
+}
+
+func (c *APC) connect(a string) (net.Conn, error) {
+
+	network, addr, err := parseAddr(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var d net.Dialer
+	if c.Dialer != nil {
+		d = *c.Dialer
+	}
+	if d.Timeout == 0 {
+		d.Timeout = c.Timeout
+	}
+
+	dl.Debug("connect to %s %s", network, addr)
+	conn, err := d.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Timeout != 0 {
+		conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 10:40 (EDT)
+// Function: test address parsing
+
+package acrpc
+
+import (
+	"testing"
+)
+
+func TestParseAddr(t *testing.T) {
+
+	tests := []struct {
+		in      string
+		network string
+		addr    string
+		ok      bool
+	}{
+		{"host:123", "tcp", "host:123", true},
+		{"tcp://host:123", "tcp", "host:123", true},
+		{"tcp4://10.1.2.3:123", "tcp4", "10.1.2.3:123", true},
+		{"tcp6://[::1]:123", "tcp6", "[::1]:123", true},
+		{"unix:///tmp/sock", "unix", "/tmp/sock", true},
+		{"udp://host:123", "", "", false},
+	}
+
+	for _, tt := range tests {
+		network, addr, err := parseAddr(tt.in)
+		if (err == nil) != tt.ok || network != tt.network || addr != tt.addr {
+			t.Errorf("parseAddr(%q) = %q, %q, %v", tt.in, network, addr, err)
+		}
+	}
+}
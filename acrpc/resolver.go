@@ -0,0 +1,130 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 10:40 (EDT)
+// Function: service discovery
+
+package acrpc
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver returns a list of endpoints, in order of preference.
+// endpoints are in the same form as APC.Addr
+type Resolver interface {
+	Resolve() ([]string, error)
+}
+
+// StaticResolver - a fixed list of endpoints
+type StaticResolver []string
+
+func (r StaticResolver) Resolve() ([]string, error) {
+	addrs := make([]string, len(r))
+	copy(addrs, r)
+	return addrs, nil
+}
+
+// SRVResolver - look up DNS SRV records
+//
+//	_Service._Proto.Name
+type SRVResolver struct {
+	Service string
+	Proto   string
+	Name    string
+}
+
+func (r *SRVResolver) Resolve() ([]string, error) {
+
+	// results are sorted by priority, and randomized by weight
+	_, srvs, err := net.LookupSRV(r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var addrs []string
+	for _, s := range srvs {
+		host := strings.TrimSuffix(s.Target, ".")
+		addrs = append(addrs, net.JoinHostPort(host, fmt.Sprintf("%d", s.Port)))
+	}
+
+	return addrs, nil
+}
+
+// FileResolver - read endpoints from a file, one per line.
+// the file is re-read whenever it changes
+type FileResolver struct {
+	File  string
+	lock  sync.Mutex
+	mtime time.Time
+	size  int64
+	addrs []string
+}
+
+func (r *FileResolver) Resolve() ([]string, error) {
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	st, err := os.Stat(r.File)
+	if err != nil {
+		if r.addrs != nil {
+			// keep using what we have
+			dl.Verbose("cannot stat '%s': %v", r.File, err)
+			return r.copyAddrs(), nil
+		}
+		return nil, err
+	}
+
+	if r.addrs == nil || !st.ModTime().Equal(r.mtime) || st.Size() != r.size {
+		addrs, err := readAddrFile(r.File)
+		if err != nil {
+			return nil, err
+		}
+
+		dl.Debug("read %d endpoints from %s", len(addrs), r.File)
+		r.addrs = addrs
+		r.mtime = st.ModTime()
+		r.size = st.Size()
+	}
+
+	return r.copyAddrs(), nil
+}
+
+func (r *FileResolver) copyAddrs() []string {
+	addrs := make([]string, len(r.addrs))
+	copy(addrs, r.addrs)
+	return addrs
+}
+
+// one endpoint per line, blank lines and #comments are ignored
+func readAddrFile(file string) ([]string, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	addrs := []string{}
+	scan := bufio.NewScanner(f)
+
+	for scan.Scan() {
+		line := scan.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		addrs = append(addrs, line)
+	}
+
+	return addrs, scan.Err()
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 09:10 (EDT)
+// Function: test resolvers
+
+package acrpc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileResolver(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "endpoints")
+	r := &FileResolver{File: file}
+
+	if _, err := r.Resolve(); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+
+	os.WriteFile(file, []byte("# servers\nhost1:123\n\n  host2:123  # backup\n"), 0644)
+	addrs, err := r.Resolve()
+	if err != nil || !reflect.DeepEqual(addrs, []string{"host1:123", "host2:123"}) {
+		t.Fatalf("wrong endpoints: %v, %v", addrs, err)
+	}
+
+	// changed => re-read
+	os.WriteFile(file, []byte("host3:123\n"), 0644)
+	addrs, err = r.Resolve()
+	if err != nil || !reflect.DeepEqual(addrs, []string{"host3:123"}) {
+		t.Fatalf("file not re-read: %v, %v", addrs, err)
+	}
+
+	// callers may scribble on the result
+	addrs[0] = "bogus"
+
+	// gone => keep the last good list
+	os.Remove(file)
+	addrs, err = r.Resolve()
+	if err != nil || !reflect.DeepEqual(addrs, []string{"host3:123"}) {
+		t.Fatalf("last good list not kept: %v, %v", addrs, err)
+	}
+}
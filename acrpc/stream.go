@@ -0,0 +1,170 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 11:05 (EDT)
+// Function: bidirectional streaming sessions
+
+package acrpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+/*
+a stream starts as a normal request+reply, with FLAG_STREAM set.
+afterwards, both sides send a sequence of frames
+    header, data(protobuf), [content]
+with FLAG_STREAM set, and the same MsgIdNo, until a frame with FLAG_EOS.
+like replies, every frame from the server (not just the first) also has
+FLAG_ISREPLY set, frames from the client do not.
+*/
+
+type Stream struct {
+	c       *APC
+	conn    *rpcConn
+	fn      uint32
+	sendEOS bool
+	recvEOS bool
+}
+
+// Stream starts a streaming session. the initial reply is unmarshaled into res,
+// and its content is returned. caller must Close the stream
+func (c *APC) Stream(fn uint32, req Marshalable, res Marshalable, content []byte) (*Stream, []byte, error) {
+
+	// connect
+	conn, err := c.dial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := &Stream{c: c, conn: conn, fn: fn}
+
+	// send request
+	err = c.sendFrame(conn, FLAG_WANTREPLY|FLAG_STREAM, fn, req, len(content))
+	if err == nil {
+		_, err = conn.Write(content)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	// read response
+	rcontent, err := s.recv(res)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	// streams are long lived
+	conn.SetDeadline(time.Time{})
+
+	return s, rcontent, nil
+}
+
+// Send sends the next message in the stream
+func (s *Stream) Send(msg Marshalable, content []byte) error {
+
+	if s.sendEOS {
+		return errors.New("send on closed stream")
+	}
+
+	err := s.c.sendFrame(s.conn, FLAG_STREAM, s.fn, msg, len(content))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.conn.Write(content)
+	return err
+}
+
+// CloseSend tells the other side we are done sending
+func (s *Stream) CloseSend() error {
+
+	if s.sendEOS {
+		return nil
+	}
+
+	s.sendEOS = true
+	return s.c.sendFrame(s.conn, FLAG_STREAM|FLAG_EOS, s.fn, nil, 0)
+}
+
+// Recv reads the next message in the stream.
+// returns io.EOF once the other side is done sending
+func (s *Stream) Recv(msg Marshalable) ([]byte, error) {
+
+	if s.recvEOS {
+		return nil, io.EOF
+	}
+
+	return s.recv(msg)
+}
+
+// SetDeadline sets a deadline for future Send and Recv calls
+func (s *Stream) SetDeadline(t time.Time) error {
+	return s.conn.SetDeadline(t)
+}
+
+// Close closes the stream
+func (s *Stream) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Stream) recv(msg Marshalable) ([]byte, error) {
+
+	prot := &acProto{}
+
+	err := binary.Read(s.conn, binary.BigEndian, prot)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, s.conn.botched(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dl.Debug("recvd prot %+v", prot)
+
+	// check prot
+	if !versionSupported(prot.Version) {
+		return nil, s.conn.botched(errors.New("protocol botched: invalid AC/RPC version"))
+	}
+	if prot.Flags&FLAG_ISREPLY == 0 || prot.Flags&FLAG_STREAM == 0 {
+		return nil, s.conn.botched(errors.New("protocol botched: invalid response"))
+	}
+	if prot.MsgIdNo != s.c.MsgId {
+		return nil, errors.New("protocol botched: invalid message id")
+	}
+	if prot.Flags&(FLAG_DATA_ENCR|FLAG_CONT_ENCR) != 0 {
+		return nil, errors.New("AC/RPC unsupported encryption algorithm")
+	}
+	if prot.Flags&FLAG_ISERROR != 0 {
+		return nil, errors.New("error flag")
+	}
+	if prot.Flags&FLAG_EOS != 0 {
+		s.recvEOS = true
+		return nil, io.EOF
+	}
+
+	data := make([]byte, prot.DataLen)
+	_, err = io.ReadFull(s.conn, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg != nil {
+		err = msg.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	content := make([]byte, prot.ContentLen)
+	_, err = io.ReadFull(s.conn, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
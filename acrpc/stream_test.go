@@ -0,0 +1,131 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 11:40 (EDT)
+// Function: test streaming
+
+package acrpc
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testMsg struct {
+	data []byte
+}
+
+func (m *testMsg) Marshal() ([]byte, error) { return m.data, nil }
+func (m *testMsg) Unmarshal(b []byte) error { m.data = append([]byte{}, b...); return nil }
+
+// echo each frame back until EOS
+func echoServer(t *testing.T, l net.Listener) {
+	echoServerFlags(t, l, FLAG_ISREPLY)
+}
+
+// the initial reply is a reply. later frames have flags
+func echoServerFlags(t *testing.T, l net.Listener, flags uint32) {
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Errorf("accept: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	for {
+		prot := &acProto{}
+		if err := binary.Read(conn, binary.BigEndian, prot); err != nil {
+			return
+		}
+		buf := make([]byte, prot.DataLen+prot.ContentLen)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		if prot.Flags&FLAG_WANTREPLY != 0 {
+			prot.Flags = (prot.Flags &^ FLAG_WANTREPLY) | FLAG_ISREPLY
+		} else {
+			prot.Flags |= flags
+		}
+		binary.Write(conn, binary.BigEndian, prot)
+		conn.Write(buf)
+
+		if prot.Flags&FLAG_EOS != 0 {
+			return
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go echoServer(t, l)
+
+	c := &APC{Addr: "unix://" + sock, MsgId: 123, Timeout: 5 * time.Second}
+
+	res := &testMsg{}
+	s, content, err := c.Stream(1, &testMsg{data: []byte("hello")}, res, []byte("content"))
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	defer s.Close()
+
+	if string(res.data) != "hello" || string(content) != "content" {
+		t.Fatalf("initial reply: got %q %q", res.data, content)
+	}
+
+	for _, m := range []string{"one", "two", "three"} {
+		if err := s.Send(&testMsg{data: []byte(m)}, []byte(m+"!")); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		content, err := s.Recv(res)
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+		if string(res.data) != m || string(content) != m+"!" {
+			t.Fatalf("recv: got %q %q, expected %q", res.data, content, m)
+		}
+	}
+
+	if err := s.CloseSend(); err != nil {
+		t.Fatalf("closesend: %v", err)
+	}
+	if _, err := s.Recv(res); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestStreamNotReply(t *testing.T) {
+
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go echoServerFlags(t, l, 0)
+
+	c := &APC{Addr: "unix://" + sock, MsgId: 123, Timeout: 5 * time.Second}
+
+	s, _, err := c.Stream(1, &testMsg{data: []byte("hello")}, &testMsg{}, nil)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	defer s.Close()
+
+	// frames from the server must be marked as replies
+	if err := s.Send(&testMsg{data: []byte("one")}, nil); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if _, err := s.Recv(&testMsg{}); err == nil {
+		t.Fatalf("expected an invalid response")
+	}
+}
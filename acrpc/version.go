@@ -0,0 +1,161 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 13:02 (EDT)
+// Function: protocol version + feature negotiation
+
+package acrpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+/*
+on first contact with a peer (if APC.Negotiate is set):
+  we send a FLAG_HELLO request (with the oldest version, so anyone can parse it)
+    data: count, versions[count], features
+  the peer replies with FLAG_HELLO|FLAG_ISREPLY
+    data: version, features
+  old peers reply with an error, or hang up, and we fall back to PHVERSION.
+
+the peer's version + features are remembered per address, until a reply
+does not make sense (eg. the peer was replaced by an older version, or hung up),
+then we negotiate again. each client uses the features both sides support.
+an error reply is just an error.
+*/
+
+const (
+	FEATURE_COMPRESS = 0x1
+	FEATURE_AUTH     = 0x2
+)
+
+// most preferred first
+var SupportedVersions = []uint32{PHVERSION3, PHVERSION}
+
+type rpcConn struct {
+	net.Conn
+	addr     string // if negotiated
+	version  uint32
+	features uint32
+}
+
+type peerInfo struct {
+	version  uint32
+	features uint32 // all of the peer's, not just the ones we use
+}
+
+var errLegacyPeer = errors.New("peer does not support negotiation")
+
+var peerLock sync.Mutex
+var peers = make(map[string]*peerInfo)
+
+func getPeer(addr string) *peerInfo {
+	peerLock.Lock()
+	defer peerLock.Unlock()
+	return peers[addr]
+}
+
+func setPeer(addr string, p *peerInfo) {
+	peerLock.Lock()
+	defer peerLock.Unlock()
+	peers[addr] = p
+}
+
+func forgetPeer(addr string) {
+	peerLock.Lock()
+	defer peerLock.Unlock()
+	delete(peers, addr)
+}
+
+// the peer did not like what we sent. negotiate again next time
+func (c *rpcConn) botched(err error) error {
+	if c.addr != "" {
+		dl.Debug("forgetting peer %s: %v", c.addr, err)
+		forgetPeer(c.addr)
+	}
+	return err
+}
+
+// PeerFeatures returns the features negotiated with the peer at addr
+// (in the same form as APC.Addr), or 0 if we have not talked yet
+func (c *APC) PeerFeatures(addr string) uint32 {
+	p := getPeer(addr)
+	if p == nil {
+		return 0
+	}
+	return p.features & c.Features
+}
+
+func versionSupported(v uint32) bool {
+	for _, sv := range SupportedVersions {
+		if v == sv {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *APC) hello(conn net.Conn) (*peerInfo, error) {
+
+	legacy := &peerInfo{version: PHVERSION}
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, uint32(len(SupportedVersions)))
+	binary.Write(buf, binary.BigEndian, SupportedVersions)
+	binary.Write(buf, binary.BigEndian, c.Features)
+
+	prot := &acProto{
+		Version: PHVERSION,
+		Flags:   FLAG_HELLO | FLAG_WANTREPLY,
+		MsgIdNo: c.MsgId,
+		DataLen: uint32(buf.Len()),
+	}
+
+	err := binary.Write(conn, binary.BigEndian, prot)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	err = binary.Read(conn, binary.BigEndian, prot)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return legacy, errLegacyPeer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dl.Debug("recvd hello %+v", prot)
+
+	if prot.Flags&FLAG_ISREPLY == 0 {
+		return nil, errors.New("protocol botched: invalid response")
+	}
+	if prot.Flags&FLAG_ISERROR != 0 || prot.Flags&FLAG_HELLO == 0 || prot.DataLen < 8 {
+		return legacy, errLegacyPeer
+	}
+
+	data := make([]byte, prot.DataLen+prot.ContentLen)
+	_, err = io.ReadFull(conn, data)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &peerInfo{
+		version:  binary.BigEndian.Uint32(data[0:4]),
+		features: binary.BigEndian.Uint32(data[4:8]),
+	}
+
+	if !versionSupported(p.version) {
+		return nil, errors.New("protocol botched: invalid AC/RPC version")
+	}
+
+	dl.Debug("negotiated version %x, features %x", p.version, p.features&c.Features)
+	return p, nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 13:40 (EDT)
+// Function: test version negotiation
+
+package acrpc
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// answer hello (unless legacy), then echo one request
+func helloServer(t *testing.T, l net.Listener, legacy bool) {
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		prot := &acProto{}
+		if err := binary.Read(conn, binary.BigEndian, prot); err != nil {
+			conn.Close()
+			continue
+		}
+		buf := make([]byte, prot.DataLen+prot.ContentLen)
+		io.ReadFull(conn, buf)
+
+		if legacy && prot.Version != PHVERSION {
+			// nor do they understand newer versions
+			conn.Close()
+			continue
+		}
+
+		if prot.Flags&FLAG_HELLO != 0 {
+			if legacy {
+				// old servers hang up on things they do not understand
+				conn.Close()
+				continue
+			}
+			reply := &acProto{Version: PHVERSION3, Flags: FLAG_HELLO | FLAG_ISREPLY, DataLen: 8}
+			binary.Write(conn, binary.BigEndian, reply)
+			binary.Write(conn, binary.BigEndian, []uint32{PHVERSION3, FEATURE_COMPRESS | FEATURE_AUTH})
+
+			if err := binary.Read(conn, binary.BigEndian, prot); err != nil {
+				conn.Close()
+				continue
+			}
+			buf = make([]byte, prot.DataLen+prot.ContentLen)
+			io.ReadFull(conn, buf)
+
+			if prot.Version != PHVERSION3 {
+				t.Errorf("expected negotiated version, got %x", prot.Version)
+			}
+		}
+
+		prot.Flags = FLAG_ISREPLY
+		binary.Write(conn, binary.BigEndian, prot)
+		conn.Write(buf)
+		conn.Close()
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+
+	for _, legacy := range []bool{false, true} {
+		sock := filepath.Join(t.TempDir(), "test.sock")
+		l, err := net.Listen("unix", sock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go helloServer(t, l, legacy)
+
+		c := &APC{Addr: "unix://" + sock, Timeout: 5 * time.Second, Negotiate: true, Features: FEATURE_COMPRESS}
+
+		for i := 0; i < 2; i++ {
+			res := &testMsg{}
+			content, err := c.Call(1, &testMsg{data: []byte("req")}, res, []byte("content"))
+			if err != nil {
+				t.Fatalf("call (legacy %v): %v", legacy, err)
+			}
+			if string(res.data) != "req" || string(content) != "content" {
+				t.Fatalf("call: got %q %q", res.data, content)
+			}
+		}
+
+		f := c.PeerFeatures(c.Addr)
+		if legacy && f != 0 {
+			t.Fatalf("legacy peer: expected no features, got %x", f)
+		}
+		if !legacy && f != FEATURE_COMPRESS {
+			t.Fatalf("expected FEATURE_COMPRESS, got %x", f)
+		}
+
+		// another client, offering more, gets more
+		c2 := &APC{Addr: c.Addr, Timeout: 5 * time.Second, Negotiate: true, Features: FEATURE_COMPRESS | FEATURE_AUTH}
+		if _, err := c2.Call(1, &testMsg{data: []byte("req")}, &testMsg{}, nil); err != nil {
+			t.Fatalf("call: %v", err)
+		}
+		f = c2.PeerFeatures(c2.Addr)
+		if !legacy && f != FEATURE_COMPRESS|FEATURE_AUTH {
+			t.Fatalf("expected FEATURE_COMPRESS|FEATURE_AUTH, got %x", f)
+		}
+		if !legacy && c.PeerFeatures(c.Addr) != FEATURE_COMPRESS {
+			t.Fatalf("features changed by another client")
+		}
+		l.Close()
+	}
+}
+
+func TestNegotiateDowngrade(t *testing.T) {
+
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go helloServer(t, l, false)
+
+	c := &APC{Addr: "unix://" + sock, Timeout: 5 * time.Second, Negotiate: true, Features: FEATURE_COMPRESS}
+	call := func() error {
+		_, err := c.Call(1, &testMsg{data: []byte("req")}, &testMsg{}, nil)
+		return err
+	}
+
+	if err := call(); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if c.PeerFeatures(c.Addr) != FEATURE_COMPRESS {
+		t.Fatalf("expected negotiated features")
+	}
+
+	// roll the peer back to an old version
+	l.Close()
+	os.Remove(sock)
+	l, err = net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go helloServer(t, l, true)
+
+	// the first call uses the old negotiation, and fails
+	if err := call(); err == nil {
+		t.Fatalf("expected the old version to be rejected")
+	}
+	// then we negotiate again
+	if err := call(); err != nil {
+		t.Fatalf("call after downgrade: %v", err)
+	}
+	if c.PeerFeatures(c.Addr) != 0 {
+		t.Fatalf("expected no features after downgrade")
+	}
+}
+
+func TestNegotiateErrorReply(t *testing.T) {
+
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// answer hello, then reply with an error
+	var hellos int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			prot := &acProto{}
+			for binary.Read(conn, binary.BigEndian, prot) == nil {
+				io.ReadFull(conn, make([]byte, prot.DataLen+prot.ContentLen))
+
+				if prot.Flags&FLAG_HELLO != 0 {
+					atomic.AddInt32(&hellos, 1)
+					reply := &acProto{Version: PHVERSION3, Flags: FLAG_HELLO | FLAG_ISREPLY, DataLen: 8}
+					binary.Write(conn, binary.BigEndian, reply)
+					binary.Write(conn, binary.BigEndian, []uint32{PHVERSION3, 0})
+					continue
+				}
+				prot.Flags = FLAG_ISREPLY | FLAG_ISERROR
+				prot.DataLen, prot.ContentLen = 0, 0
+				binary.Write(conn, binary.BigEndian, prot)
+				break
+			}
+			conn.Close()
+		}
+	}()
+
+	c := &APC{Addr: "unix://" + sock, Timeout: 5 * time.Second, Negotiate: true}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Call(1, &testMsg{data: []byte("req")}, &testMsg{}, nil); err == nil {
+			t.Fatalf("expected error reply")
+		}
+	}
+
+	// an error reply is not a reason to negotiate again
+	if n := atomic.LoadInt32(&hellos); n != 1 {
+		t.Fatalf("expected 1 hello, got %d", n)
+	}
+}
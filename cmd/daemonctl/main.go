@@ -0,0 +1,45 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 18:20 (EDT)
+// Function: control a running daemon
+
+// daemonctl - control a daemon.Ize'd program
+//
+//	daemonctl [-dir /var/run] [-sock path] prog status|restart|stop|reload|reopen-logs|scale N
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jaw0/acgo/daemon"
+)
+
+func main() {
+
+	dir := flag.String("dir", daemon.PidfileDir, "pid file directory")
+	sock := flag.String("sock", "", "control socket")
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [-dir dir] [-sock path] prog status|restart|stop|reload|reopen-logs|scale N\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	prog := flag.Arg(0)
+	cmd := strings.Join(flag.Args()[1:], " ")
+
+	if *sock == "" {
+		*sock = daemon.ControlPath(*dir, prog)
+	}
+
+	res, err := daemon.SendControl(*sock, cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", prog, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(res)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 09:15 (EDT)
+// Function: supervise other programs
+
+/*
+keep a helper program running:
+    s, err := daemon.Supervise(&daemon.Command{
+        Path: "/usr/local/bin/helper",
+        Args: []string{"-v"},
+        Logfile: "/var/log/helper.log",
+    })
+    ...
+    s.Stop()
+
+the helper is restarted per the RestartPolicy, same as for Ize.
+
+signals sent to the program are not passed on to the helper, unless
+listed in Forward. to pass one on explicitly:
+    s.Signal(syscall.SIGHUP)
+*/
+
+package daemon
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+type Command struct {
+	Path    string
+	Args    []string // not including the program name
+	Env     []string // default: ours
+	Dir     string
+	Logfile string    // save output here (rotated), or
+	Output  io.Writer // write output here. default: discard
+	Restart *RestartPolicy
+	// after Stop, how long to wait before killing it. default: KillTimeout
+	KillTimeout time.Duration
+	// catch these signals, and pass them on to the helper. default: none
+	Forward []os.Signal
+}
+
+// Supervise starts cmd, and keeps it running, until Stop
+func Supervise(cmd *Command) (*Supervisor, error) {
+
+	prog, err := exec.LookPath(cmd.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Supervisor{
+		Prog:        prog,
+		Args:        append([]string{cmd.Path}, cmd.Args...),
+		Env:         cmd.Env,
+		Dir:         cmd.Dir,
+		Logfile:     cmd.Logfile,
+		Output:      cmd.Output,
+		Restart:     cmd.Restart,
+		KillTimeout: cmd.KillTimeout,
+		MemoryWarn:  MemoryWarn,
+		Forward:     cmd.Forward,
+	}
+
+	if s.KillTimeout == 0 {
+		s.KillTimeout = KillTimeout
+	}
+
+	s.Start()
+	return s, nil
+}
+
+// Start runs the supervisor in the background
+func (s *Supervisor) Start() {
+
+	if s.Signals == nil {
+		s.Signals = make(chan os.Signal, 5)
+	}
+	s.done = make(chan struct{})
+
+	go func() {
+		s.result = s.Run()
+		close(s.done)
+	}()
+}
+
+// Signal passes a signal on to the children
+func (s *Supervisor) Signal(n os.Signal) {
+	select {
+	case s.Signals <- n:
+	case <-s.done:
+	}
+}
+
+// Stop stops the children, and waits for the supervisor to finish
+func (s *Supervisor) Stop() int {
+	s.Signal(syscall.SIGTERM)
+	return s.Wait()
+}
+
+// Wait waits for the supervisor to finish, and returns its exit code
+func (s *Supervisor) Wait() int {
+	<-s.done
+	return s.result
+}
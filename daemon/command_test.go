@@ -0,0 +1,158 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 09:40 (EDT)
+// Function: test supervising other programs
+
+package daemon
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestSupervise(t *testing.T) {
+
+	out := &syncBuffer{}
+	dir := t.TempDir()
+
+	s, err := Supervise(&Command{
+		Path:        "sh",
+		Args:        []string{"-c", "echo run in $PWD $FOO; sleep 0.05; exit 3"},
+		Env:         []string{"FOO=bar"},
+		Dir:         dir,
+		Output:      out,
+		Restart:     &RestartPolicy{Delay: 10 * time.Millisecond},
+		KillTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// let it crash + restart a few times
+	deadline := time.Now().Add(10 * time.Second)
+	for strings.Count(out.String(), "\n") < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code := s.Stop(); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("expected restarts, got %q", out.String())
+	}
+	if lines[0] != "run in "+dir+" bar" {
+		t.Fatalf("unexpected output %q", lines[0])
+	}
+}
+
+func TestSuperviseNoLeak(t *testing.T) {
+
+	cycle := func() {
+		s, err := Supervise(&Command{
+			Path:        "sleep",
+			Args:        []string{"10"},
+			Restart:     &RestartPolicy{Delay: time.Hour},
+			KillTimeout: time.Hour,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		s.Stop()
+	}
+
+	// the kill timers are still pending
+	checkNoLeak(t, cycle)
+}
+
+func TestSuperviseWatchNoLeak(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "prog.conf")
+	os.WriteFile(file, []byte("a"), 0644)
+
+	prog, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cycle := func() {
+		s := &Supervisor{
+			Prog:          prog,
+			Args:          []string{"sleep", "10"},
+			Restart:       &RestartPolicy{Delay: time.Hour},
+			KillTimeout:   time.Hour,
+			Watch:         []string{file},
+			WatchDebounce: 10 * time.Millisecond,
+		}
+		s.Start()
+		time.Sleep(10 * time.Millisecond)
+		s.Stop()
+	}
+
+	checkNoLeak(t, cycle)
+}
+
+// run cycle repeatedly. goroutines + files should not accumulate
+func checkNoLeak(t *testing.T, cycle func()) {
+
+	// warm up
+	cycle()
+	time.Sleep(50 * time.Millisecond)
+	before := runtime.NumGoroutine()
+	fdsBefore := openFiles()
+
+	for i := 0; i < 10; i++ {
+		cycle()
+	}
+
+	var after, fdsAfter int
+	for i := 0; i < 100; i++ {
+		after = runtime.NumGoroutine()
+		fdsAfter = openFiles()
+		if after <= before && fdsAfter <= fdsBefore {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after > before {
+		t.Fatalf("goroutines leaked: %d before, %d after", before, after)
+	}
+	if fdsAfter > fdsBefore {
+		t.Fatalf("files leaked: %d before, %d after", fdsBefore, fdsAfter)
+	}
+}
+
+// -1 if we cannot tell (no /proc)
+func openFiles() int {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(fds)
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 17:55 (EDT)
+// Function: supervisor control socket
+
+/*
+the supervisor listens on a unix domain socket, by default:
+    PidfileDir/prog.ctl
+
+one command per connection:
+    status, restart, stop, reload, reopen-logs, scale N
+the reply is text, errors start with "error:"
+*/
+
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// enable the control socket
+var Control = false
+
+// override the default control socket location
+var ControlSocket = ""
+
+// the socket we are listening on
+var controlPath = ""
+
+type ctlReq struct {
+	cmd   string
+	reply chan string
+}
+
+// ControlPath returns the conventional control socket for prog
+func ControlPath(dir, prog string) string {
+	return dir + "/" + prog + ".ctl"
+}
+
+func controlSocket(name string, cf *config) string {
+
+	if !cf.control {
+		return ""
+	}
+	if ControlSocket != "" {
+		return ControlSocket
+	}
+	if cf.pidfileDir != "" {
+		return ControlPath(cf.pidfileDir, name)
+	}
+	return ""
+}
+
+// SendControl sends a command to a running daemon's control socket
+func SendControl(sock string, cmd string) (string, error) {
+
+	conn, err := net.DialTimeout("unix", sock, 5*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(KillTimeout + 5*time.Second))
+
+	_, err = fmt.Fprintf(conn, "%s\n", cmd)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return "", err
+	}
+
+	reply := strings.TrimSpace(string(res))
+	if strings.HasPrefix(reply, "error:") {
+		return "", fmt.Errorf("%s", strings.TrimSpace(reply[6:]))
+	}
+
+	return reply, nil
+}
+
+func (s *Supervisor) listenControl(sock string) error {
+
+	// remove a stale socket. we hold the pid file, so no one else is using it
+	os.Remove(sock)
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		return err
+	}
+	os.Chmod(sock, 0600)
+	controlPath = sock
+
+	go func() {
+		<-s.quit
+		l.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				select {
+				case <-s.quit:
+				default:
+					dl.Problem("control socket: %v", err)
+				}
+				return
+			}
+			go s.serveControl(conn)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Supervisor) serveControl(conn net.Conn) {
+
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	req := &ctlReq{
+		cmd:   strings.TrimSpace(line),
+		reply: make(chan string, 1),
+	}
+
+	dl.Verbose("control: %s", req.cmd)
+	select {
+	case s.ctlchan <- req:
+		fmt.Fprintf(conn, "%s\n", <-req.reply)
+	case <-s.quit:
+		fmt.Fprintf(conn, "error: stopped\n")
+	}
+}
+
+// handle a control request, in the supervisor's main loop
+func (s *Supervisor) control(req *ctlReq) {
+
+	args := strings.Fields(req.cmd)
+	if len(args) == 0 {
+		req.reply <- "error: no command"
+		return
+	}
+
+	switch args[0] {
+	case "status":
+		req.reply <- s.status()
+		return
+	case "stop":
+		s.stop()
+	case "restart":
+		s.restartAll()
+	case "reload":
+		if s.running() == 0 {
+			req.reply <- "error: not running"
+			return
+		}
+		s.signal(syscall.SIGHUP)
+	case "reopen-logs":
+		if s.logw != nil {
+			err := s.logw.Reopen()
+			if err != nil {
+				req.reply <- fmt.Sprintf("error: %v", err)
+				return
+			}
+		}
+		if s.self && isHandled(syscall.SIGUSR1) {
+			// the children have logs to reopen too
+			for _, c := range s.children {
+				if c.proc != nil && !c.retire {
+					c.proc.Signal(syscall.SIGUSR1)
+				}
+			}
+		}
+	case "scale":
+		n := 0
+		if len(args) == 2 {
+			n, _ = strconv.Atoi(args[1])
+		}
+		if s.Workers == 0 || n < 1 {
+			req.reply <- "error: cannot scale"
+			return
+		}
+		s.scale(n)
+	default:
+		req.reply <- fmt.Sprintf("error: unknown command '%s'", req.cmd)
+		return
+	}
+
+	req.reply <- "ok"
+}
+
+func (s *Supervisor) status() string {
+
+	var b strings.Builder
+	st := s.snapshot(s.state())
+	now := st.Updated
+
+	fmt.Fprintf(&b, "program  %s\n", st.Program)
+	if st.Version != "" {
+		fmt.Fprintf(&b, "version  %s\n", st.Version)
+	}
+	fmt.Fprintf(&b, "pid      %d\n", st.Pid)
+	fmt.Fprintf(&b, "uptime   %s\n", now.Sub(st.Started).Round(time.Second))
+
+	for _, c := range st.Children {
+		if s.Workers > 0 {
+			fmt.Fprintf(&b, "worker   %d\n", c.Worker)
+		}
+		if c.Pid != 0 {
+			fmt.Fprintf(&b, "child    %d\n", c.Pid)
+			fmt.Fprintf(&b, "running  %s\n", now.Sub(c.Started).Round(time.Second))
+		} else {
+			fmt.Fprintf(&b, "child    not running\n")
+		}
+
+		fmt.Fprintf(&b, "restarts %d\n", c.Restarts)
+		if c.LastExit != "" {
+			fmt.Fprintf(&b, "exit     %s\n", c.LastExit)
+		}
+		if c.Usage != nil {
+			fmt.Fprintf(&b, "usage    %s\n", c.Usage)
+		}
+	}
+
+	if st.Stats.Runs > 0 {
+		fmt.Fprintf(&b, "runs     %d (%d crashed)\n", st.Stats.Runs, st.Stats.Crashes)
+		fmt.Fprintf(&b, "total    cpu %s user, %s sys\n",
+			st.Stats.User.Round(time.Millisecond), st.Stats.System.Round(time.Millisecond))
+	}
+
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 17:30 (EDT)
+// Function: test the control socket
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestControl(t *testing.T) {
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		p.onSignal = map[os.Signal]*ExitStatus{
+			syscall.SIGTERM: {Code: 0},
+			// no reload action: a new binary
+			syscall.SIGHUP: {Code: ExitRestart},
+		}
+	}}
+	started := func() int {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		return len(f.procs)
+	}
+	waitFor := func(what string, cond func() bool) {
+		for end := time.Now().Add(10 * time.Second); !cond(); {
+			if time.Now().After(end) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	s := testSupervisor(f)
+	s.Workers = 2
+	s.Restart.RestartDelay = time.Minute
+	s.Restart.MinUptime = time.Minute
+	s.ctlSock = filepath.Join(t.TempDir(), "test.ctl")
+	defer func() { controlPath = "" }()
+
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+	waitFor("startup", func() bool { return started() == 2 })
+
+	send := func(cmd string) (string, error) {
+		return SendControl(s.ctlSock, cmd)
+	}
+
+	res, err := send("status")
+	if err != nil || !strings.Contains(res, "program  /bin/test") || strings.Count(res, "worker ") != 2 {
+		t.Fatalf("status: %v\n%s", err, res)
+	}
+
+	errors := []struct {
+		cmd string
+		err string
+	}{
+		{"", "no command"},
+		{"bogus", "unknown command 'bogus'"},
+		{"scale", "cannot scale"},
+		{"scale 0", "cannot scale"},
+	}
+	for _, e := range errors {
+		if _, err := send(e.cmd); err == nil || err.Error() != e.err {
+			t.Fatalf("%q: expected %q, got %v", e.cmd, e.err, err)
+		}
+	}
+
+	if _, err := send("scale 3"); err != nil || started() != 3 || s.running() != 3 {
+		t.Fatalf("scale: %v, %d started", err, started())
+	}
+
+	// the children restart right away. not a crash, not delayed
+	if _, err := send("reload"); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	waitFor("reload", func() bool { return started() == 6 })
+	if st := s.Stats(); st.Crashes != 0 {
+		t.Fatalf("reload counted as a crash")
+	}
+
+	// the children only get SIGUSR1 if they do something with it
+	if _, err := send("reopen-logs"); err != nil {
+		t.Fatalf("reopen-logs: %v", err)
+	}
+	defer func() { sigActions = make(map[os.Signal][]func()) }()
+	OnReopenLogs(func() {})
+	if _, err := send("reopen-logs"); err != nil {
+		t.Fatalf("reopen-logs: %v", err)
+	}
+
+	if _, err := send("restart"); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	waitFor("restart", func() bool { return started() == 9 })
+
+	for i := 3; i < 6; i++ {
+		sigs := f.procs[i].Signals()
+		if len(sigs) != 2 || sigs[0] != syscall.SIGUSR1 || sigs[1] != syscall.SIGTERM {
+			t.Fatalf("child %d: expected USR1, TERM, got %v", i, sigs)
+		}
+	}
+	for _, d := range f.delays {
+		if d != 0 {
+			t.Fatalf("expected immediate restarts, got %v", f.delays)
+		}
+	}
+
+	if _, err := send("stop"); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Fatalf("expected 0, got %d", code)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("supervisor did not stop")
+	}
+
+	if _, err := send("status"); err == nil {
+		t.Fatalf("expected the socket to be gone")
+	}
+}
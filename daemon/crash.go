@@ -0,0 +1,100 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 10:10 (EDT)
+// Function: report crashes
+
+/*
+each abnormal exit of a child is reported via diag.Problem
+(syslog + email, per the diag config). as are children that cannot
+be started, hang, or have to be killed.
+
+to keep a crash loop from flooding inboxes, at most CrashReportLimit
+reports are sent per CrashReportWindow, the rest only go to syslog.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var CrashReportLimit = 5
+var CrashReportWindow = time.Hour
+
+type crashReporter struct {
+	sent       []time.Time
+	suppressed int
+}
+
+// may we send a report now?
+func (r *crashReporter) allow(now time.Time) bool {
+
+	recent := r.sent[:0]
+	for _, t := range r.sent {
+		if now.Sub(t) < CrashReportWindow {
+			recent = append(recent, t)
+		}
+	}
+	r.sent = recent
+
+	if CrashReportLimit > 0 && len(r.sent) >= CrashReportLimit {
+		r.suppressed++
+		return false
+	}
+
+	r.sent = append(r.sent, now)
+	return true
+}
+
+func (s *Supervisor) reportCrash(c *child, pid int, st *ExitStatus, next string) {
+
+	now := s.Now()
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: child %d ", s.name, pid)
+	if s.Workers > 0 {
+		fmt.Fprintf(&b, "(worker %d) ", c.index)
+	}
+	if st.Signal != 0 {
+		fmt.Fprintf(&b, "killed by signal\n")
+	} else {
+		fmt.Fprintf(&b, "crashed\n")
+	}
+
+	fmt.Fprintf(&b, "status:   %s\n", st)
+	if st.Signal != 0 {
+		fmt.Fprintf(&b, "signal:   %d (%s)\n", int(st.Signal), st.Signal)
+	}
+	fmt.Fprintf(&b, "uptime:   %s\n", now.Sub(c.started).Round(time.Millisecond))
+	if st.Usage != nil {
+		fmt.Fprintf(&b, "usage:    %s\n", st.Usage)
+	}
+	fmt.Fprintf(&b, "restarts: %d\n", c.restarts)
+	fmt.Fprintf(&b, "next:     %s\n", next)
+
+	if s.logw != nil {
+		if tail := s.logw.Tail(); tail != "" {
+			fmt.Fprintf(&b, "\nlast output:\n%s\n", tail)
+		}
+	}
+
+	s.report(b.String())
+}
+
+// send the report, unless we have sent too many already
+func (s *Supervisor) report(msg string) {
+
+	if !s.crashes.allow(s.Now()) {
+		dl.Verbose("%s", msg)
+		return
+	}
+
+	if s.crashes.suppressed > 0 {
+		msg += fmt.Sprintf("\n(%d more problems were not reported)\n", s.crashes.suppressed)
+		s.crashes.suppressed = 0
+	}
+
+	dl.Problem("%s", msg)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 10:35 (EDT)
+// Function: test crash report rate limiting
+
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrashRateLimit(t *testing.T) {
+
+	r := &crashReporter{}
+	now := time.Now()
+
+	for i := 0; i < CrashReportLimit; i++ {
+		if !r.allow(now) {
+			t.Fatalf("report %d should be allowed", i)
+		}
+	}
+
+	if r.allow(now) || r.allow(now.Add(time.Minute)) {
+		t.Fatalf("report should be suppressed")
+	}
+	if r.suppressed != 2 {
+		t.Fatalf("expected 2 suppressed, got %d", r.suppressed)
+	}
+
+	if !r.allow(now.Add(CrashReportWindow)) {
+		t.Fatalf("report should be allowed after the window")
+	}
+}
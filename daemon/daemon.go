@@ -7,13 +7,14 @@
 package daemon
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path"
-	"sync"
+	"path/filepath"
+	"strings"
 	"syscall"
-	"time"
 )
 
 const (
@@ -26,24 +27,79 @@ const ENVVAR = "_dmode"
 var PidfileDir = "/var/run"
 var Pidfile = ""
 
-// daemon.Ize() - run program as a daemon
+// stay in the foreground, without supervision. (eg. for debugging)
+// the pid file, shutdown hooks, and signal actions still work.
+// programs that want a -foreground flag can bind it to this with flag.BoolVar
+var Foreground = false
+
+// daemon.Ize() - run program as a daemon. exits if it cannot
 func Ize() {
 
+	if err := IzeWith(nil); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot daemonize: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// IzeWith applies the options, then runs as a daemon.
+// returns an error if it cannot. (in the child, the program should exit,
+// and will be restarted per the RestartPolicy)
+func IzeWith(opts *Options) error {
+
+	cf, err := opts.config()
+	if err != nil {
+		return err
+	}
+
 	mode := os.Getenv(ENVVAR)
 	prog, err := os.Executable()
 
-	if err != nil {
-		fmt.Printf("cannot daemonize: %v", err)
-		os.Exit(2)
+	if cf.pidfile == "" && cf.pidfileDir != "" {
+		name := path.Base(os.Args[0])
+		if err == nil {
+			name = path.Base(prog)
+		}
+		cf.pidfile = cf.pidfileDir + "/" + name + ".pid"
+	}
+
+	if mode == "" && (cf.foreground || UnderSystemd()) {
+		// no backgrounding. systemd (if any) does the supervising
+		return runForeground(cf)
 	}
 
 	if mode == "" {
 		// initial execution
+		// complain now, while someone is watching
+		if err == nil {
+			err = canReexec(prog)
+		}
+		if err == nil {
+			err = checkPrivileges(cf)
+		}
+		if err != nil {
+			return err
+		}
+
+		if cf.pidfile != "" {
+			if err := checkPidFileWritable(cf.pidfile); err != nil {
+				return fmt.Errorf("cannot save pidfile: %v", err)
+			}
+			if err := claimPidFile(cf.pidfile, cf.takeover); err != nil {
+				return err
+			}
+		}
+
 		// switch to the background
 		os.Setenv(ENVVAR, "1")
-		dn, _ := os.OpenFile(os.DevNull, os.O_RDWR, 0666)
+		dn, err := os.OpenFile(os.DevNull, os.O_RDWR, 0666)
+		if err != nil {
+			return err
+		}
 		pa := &os.ProcAttr{Files: []*os.File{dn, dn, dn}}
-		os.StartProcess(prog, os.Args, pa)
+		_, err = os.StartProcess(prog, os.Args, pa)
+		if err != nil {
+			return err
+		}
 		os.Exit(0)
 	}
 
@@ -51,103 +107,76 @@ func Ize() {
 
 	if mode == "2" {
 		// run and be the main program
-		return
-	}
-
-	var sigchan = make(chan os.Signal, 5)
-	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
-
-	if Pidfile == "" && PidfileDir != "" {
-		Pidfile = PidfileDir + "/" + path.Base(prog) + ".pid"
-	}
-	if Pidfile != "" {
-		SavePidFile(Pidfile)
+		// nobody is watching anymore, the supervisor will restart us
+		if err := dropPrivileges(cf); err != nil {
+			return fmt.Errorf("cannot drop privileges: %v", err)
+		}
+		startHeartbeat()
+		return nil
 	}
 
 	// watch + restart
-	for {
-		os.Setenv(ENVVAR, "2")
-		dn, _ := os.OpenFile(os.DevNull, os.O_RDWR, 0666)
-		pa := &os.ProcAttr{Files: []*os.File{dn, dn, dn}}
-		p, err := os.StartProcess(prog, os.Args, pa)
-		if err != nil {
-			fmt.Printf("cannot start %s: %v", prog, err)
-			os.Exit(2)
-		}
-
-		stop := make(chan struct{})
-		var wg sync.WaitGroup
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-			select {
-			case <-stop:
-				return
-			case n := <-sigchan:
-				// pass the signal on through to the running program
-				p.Signal(n)
-			}
-		}()
+	os.Exit(newSupervisor(prog, cf).Run())
+	return nil
+}
 
-		st, _ := p.Wait()
-		if !st.Exited() {
-			continue
-		}
-		if st.Success() {
-			// done
-			if Pidfile != "" {
-				RemovePidFile(Pidfile)
-			}
-			os.Exit(0)
-		}
+// the supervisor re-executes the program, make sure it will be able to
+func canReexec(prog string) error {
 
-		close(stop)
-		wg.Wait()
-		time.Sleep(5 * time.Second)
+	if _, err := os.Stat(prog); err != nil {
+		return fmt.Errorf("cannot find executable: %v", err)
 	}
-}
-
-func SavePidFile(file string) error {
 
-	f, err := os.Create(file)
-	if err != nil {
-		return err
+	if strings.HasSuffix(prog, ".test") {
+		return fmt.Errorf("cannot daemonize a test binary (use Foreground)")
 	}
 
-	f.WriteString(fmt.Sprintf("%d\n", os.Getpid()))
+	// go run builds into a temp dir, and removes it once we exit
+	tmp := filepath.Clean(os.TempDir()) + string(filepath.Separator)
+	if strings.HasPrefix(prog, tmp) && strings.Contains(prog, "go-build") {
+		return fmt.Errorf("cannot daemonize from 'go run' (build it, or use Foreground)")
+	}
 
-	prog, err := os.Executable()
-	if err == nil {
-		f.WriteString(fmt.Sprintf("# %s", prog))
-		for _, arg := range os.Args[1:] {
-			f.WriteString(" ")
-			f.WriteString(arg)
-		}
-		f.WriteString("\n")
+	if flag.Lookup("test.v") != nil {
+		return fmt.Errorf("cannot daemonize a test binary (use Foreground)")
 	}
 
-	f.Close()
 	return nil
 }
 
-func RemovePidFile(file string) {
-	os.Remove(file)
-}
-
+// SigExiter runs the shutdown hooks and exits on a signal.
+// signals with actions registered (see OnSignal) run them instead
 func SigExiter() {
 	var sigchan = make(chan os.Signal, 5)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
+	if sigs := handledSignals(); len(sigs) != 0 {
+		// NB - an empty list means all signals
+		signal.Notify(sigchan, sigs...)
+	}
 
-	select {
-	case n := <-sigchan:
-		switch n {
-		case syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT:
-			os.Exit(0)
-		case syscall.SIGHUP:
-			os.Exit(1)
-		default:
-			os.Exit(2)
+	var n os.Signal
+	for {
+		n = <-sigchan
+		if !runSignalActions(n) {
+			break
+		}
+	}
+
+	go func() {
+		// a 2nd signal means now
+		for n := range sigchan {
+			if !isHandled(n) {
+				os.Exit(2)
+			}
 		}
+	}()
+
+	switch n {
+	case syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT:
+		Shutdown(ExitFinished)
+	case syscall.SIGHUP:
+		Shutdown(ExitRestart)
+	default:
+		Shutdown(2)
 	}
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 18:30 (EDT)
+// Function: test daemonizing
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCanReexec(t *testing.T) {
+
+	exe, _ := os.Executable()
+	if err := canReexec(exe); err == nil || !strings.Contains(err.Error(), "test binary") {
+		t.Fatalf("expected test binary error, got %v", err)
+	}
+
+	dir, err := os.MkdirTemp("", "go-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prog := filepath.Join(dir, "exe", "prog")
+	os.MkdirAll(filepath.Dir(prog), 0755)
+	os.WriteFile(prog, nil, 0755)
+
+	if err := canReexec(prog); err == nil || !strings.Contains(err.Error(), "go run") {
+		t.Fatalf("expected go run error, got %v", err)
+	}
+}
+
+const deletedHelperVar = "_dtest_deleted"
+
+// runs in a subprocess, from a copy of the test binary: delete it, then check
+func TestCanReexecHelper(t *testing.T) {
+
+	if os.Getenv(deletedHelperVar) == "" {
+		return
+	}
+
+	prog, err := os.Executable()
+	if err == nil {
+		os.Remove(prog)
+		err = canReexec(prog)
+	}
+	if err != nil {
+		os.Stdout.WriteString(err.Error() + "\n")
+	}
+	os.Exit(0)
+}
+
+func TestCanReexecDeleted(t *testing.T) {
+
+	b, err := os.ReadFile(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog := filepath.Join(t.TempDir(), "prog")
+	if err := os.WriteFile(prog, b, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(prog, "-test.run=^TestCanReexecHelper$")
+	cmd.Env = append(os.Environ(), deletedHelperVar+"=1")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("helper: %v", err)
+	}
+	if !strings.Contains(string(out), "cannot find executable") {
+		t.Fatalf("expected not found error, got %q", out)
+	}
+}
+
+func TestIzeForeground(t *testing.T) {
+
+	pidfile := filepath.Join(t.TempDir(), "test.pid")
+	t.Setenv(ENVVAR, "")
+
+	err := IzeWith(&Options{Foreground: true, Pidfile: pidfile})
+	if err != nil {
+		t.Fatalf("ize: %v", err)
+	}
+	defer RemovePidFile(pidfile)
+
+	b, _ := os.ReadFile(pidfile)
+	if !strings.HasPrefix(string(b), strconv.Itoa(os.Getpid())+"\n") {
+		t.Fatalf("wrong pid file: %q", b)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 20:40 (EDT)
+// Function: detect + restart a hung child
+
+/*
+heartbeat:
+    the supervisor passes the child a pipe. the child writes to it
+    every Heartbeat, as long as HealthCheck (if set) returns true.
+    HealthCheck should exercise the program's main locks + paths.
+
+probe:
+    the supervisor periodically checks HealthProbe:
+        http://host:port/path  - expects a 2xx response
+        tcp://host:port        - expects to connect
+        unix:///path/to/sock   - expects to connect
+
+if the child misses its heartbeats, or fails HealthProbeFailures probes in a row,
+it is sent SIGABRT (so the go runtime dumps the goroutines to the log),
+and then killed, and restarted.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const HEARTBEATVAR = "_dheartbeat"
+
+// child sends a heartbeat this often. 0 = disabled
+var Heartbeat time.Duration = 0
+
+// restart the child if no heartbeat for this long. default 3 * Heartbeat
+var HeartbeatTimeout time.Duration = 0
+
+var HealthProbe = ""
+var HealthProbeInterval = 10 * time.Second
+var HealthProbeFailures = 3
+
+// allow the child time to start up before expecting it to be healthy
+var HealthGrace = 30 * time.Second
+
+// how long to wait after SIGABRT before SIGKILL
+const hungKillTimeout = 5 * time.Second
+
+// in the supervisor: create the heartbeat pipe
+// returns the file for the child, and the read end
+func heartbeatPipe() (*os.File, *os.File, error) {
+
+	if Heartbeat == 0 {
+		return nil, nil, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, r, nil
+}
+
+// in the supervisor: how long to wait for a heartbeat
+func heartbeatTimeout() time.Duration {
+	if HeartbeatTimeout == 0 {
+		return 3 * Heartbeat
+	}
+	return HeartbeatTimeout
+}
+
+// in the supervisor: watch for heartbeats. signals hung if they stop
+func monitorHeartbeat(r *os.File, grace, timeout time.Duration, hung chan string, done chan struct{}) {
+
+	beats := make(chan struct{})
+
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			_, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+			select {
+			case beats <- struct{}{}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(grace + timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-beats:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		case <-timer.C:
+			select {
+			case hung <- fmt.Sprintf("no heartbeat in %s", timeout):
+			case <-done:
+			}
+			return
+		}
+	}
+}
+
+// in the supervisor: probe the child. signals hung if it fails
+func monitorProbe(target string, grace, ival time.Duration, failures int, hung chan string, done chan struct{}) {
+
+	select {
+	case <-time.After(grace):
+	case <-done:
+		return
+	}
+
+	fails := 0
+
+	for {
+		err := probe(target, ival)
+		if err == nil {
+			fails = 0
+		} else {
+			fails++
+			dl.Verbose("health probe failed: %v", err)
+		}
+
+		if fails >= failures {
+			select {
+			case hung <- fmt.Sprintf("health probe failed %d times: %v", fails, err):
+			case <-done:
+			}
+			return
+		}
+
+		select {
+		case <-time.After(ival):
+		case <-done:
+			return
+		}
+	}
+}
+
+func probe(target string, timeout time.Duration) error {
+
+	switch {
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		client := &http.Client{Timeout: timeout}
+		res, err := client.Get(target)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("status %s", res.Status)
+		}
+		return nil
+
+	case strings.HasPrefix(target, "tcp://"), strings.HasPrefix(target, "unix://"):
+		i := strings.Index(target, "://")
+		conn, err := net.DialTimeout(target[:i], target[i+3:], timeout)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	}
+
+	return fmt.Errorf("invalid health probe '%s'", target)
+}
+
+// in the child: send heartbeats
+func startHeartbeat() {
+
+	fd, err := strconv.Atoi(os.Getenv(HEARTBEATVAR))
+	os.Unsetenv(HEARTBEATVAR)
+	if err != nil || Heartbeat == 0 {
+		return
+	}
+
+	f := os.NewFile(uintptr(fd), "heartbeat")
+	beat, check := Heartbeat, HealthCheck
+
+	go func() {
+		for {
+			if check == nil || check() {
+				_, err := f.Write([]byte{1})
+				if err != nil {
+					return
+				}
+			} else {
+				dl.Verbose("health check failed, skipping heartbeat")
+			}
+			time.Sleep(beat)
+		}
+	}()
+}
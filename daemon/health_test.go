@@ -0,0 +1,240 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 16:40 (EDT)
+// Function: test heartbeats + health probes
+
+package daemon
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// short timeouts, for testing
+func setHealth(t *testing.T, beat, timeout, grace time.Duration) {
+
+	hb, ht, hg := Heartbeat, HeartbeatTimeout, HealthGrace
+	hp, hi, hf, hc := HealthProbe, HealthProbeInterval, HealthProbeFailures, HealthCheck
+	t.Cleanup(func() {
+		Heartbeat, HeartbeatTimeout, HealthGrace = hb, ht, hg
+		HealthProbe, HealthProbeInterval, HealthProbeFailures, HealthCheck = hp, hi, hf, hc
+	})
+
+	Heartbeat, HeartbeatTimeout, HealthGrace = beat, timeout, grace
+	HealthProbeInterval = 10 * time.Millisecond
+}
+
+func TestMonitorHeartbeat(t *testing.T) {
+
+	beat, timeout := 10*time.Millisecond, 100*time.Millisecond
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	hung := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go monitorHeartbeat(r, 50*time.Millisecond, timeout, hung, done)
+
+	// healthy, for a while
+	end := time.Now().Add(400 * time.Millisecond)
+	for time.Now().Before(end) {
+		w.Write([]byte{1})
+		select {
+		case msg := <-hung:
+			t.Fatalf("unexpected hung: %s", msg)
+		case <-time.After(beat):
+		}
+	}
+
+	// then not
+	start := time.Now()
+	select {
+	case <-hung:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("hang not detected")
+	}
+	if d := time.Since(start); d < timeout/2 {
+		t.Fatalf("detected too soon: %v", d)
+	}
+}
+
+func TestMonitorHeartbeatDone(t *testing.T) {
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	hung := make(chan string)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		monitorHeartbeat(r, 0, 50*time.Millisecond, hung, done)
+		close(exited)
+	}()
+
+	// the child exited. no complaints
+	close(done)
+	select {
+	case <-exited:
+	case msg := <-hung:
+		t.Fatalf("unexpected hung: %s", msg)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("monitor did not stop")
+	}
+}
+
+func TestStartHeartbeat(t *testing.T) {
+
+	setHealth(t, 10*time.Millisecond, 0, 0)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the heartbeat goroutine stops once the pipe is closed
+	defer r.Close()
+
+	// the child's copy
+	fd, err := syscall.Dup(int(w.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	var healthy int32 = 1
+	HealthCheck = func() bool { return atomic.LoadInt32(&healthy) != 0 }
+
+	t.Setenv(HEARTBEATVAR, strconv.Itoa(fd))
+	startHeartbeat()
+
+	if os.Getenv(HEARTBEATVAR) != "" {
+		t.Fatalf("env not cleared")
+	}
+
+	r.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1)
+	if _, err := r.Read(buf); err != nil {
+		t.Fatalf("no heartbeat: %v", err)
+	}
+
+	// no heartbeats while unhealthy
+	atomic.StoreInt32(&healthy, 0)
+	time.Sleep(50 * time.Millisecond)
+	r.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	for {
+		// drain any sent before we noticed
+		if _, err := r.Read(make([]byte, 64)); err != nil {
+			break
+		}
+	}
+	r.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := r.Read(buf); err == nil {
+		t.Fatalf("heartbeat while unhealthy")
+	}
+}
+
+func TestProbe(t *testing.T) {
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+
+	tl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	ul, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ul.Close()
+
+	// and one that is not listening
+	cl, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := cl.Addr().String()
+	cl.Close()
+
+	tests := []struct {
+		target string
+		ok     bool
+	}{
+		{ok.URL + "/health", true},
+		{bad.URL + "/health", false},
+		{"tcp://" + tl.Addr().String(), true},
+		{"unix://" + sock, true},
+		{"tcp://" + closed, false},
+		{"unix://" + sock + ".none", false},
+		{"ftp://example.com/", false},
+	}
+
+	for _, test := range tests {
+		err := probe(test.target, time.Second)
+		if (err == nil) != test.ok {
+			t.Errorf("probe %s: expected ok=%v, got %v", test.target, test.ok, err)
+		}
+	}
+}
+
+func TestSupervisorHung(t *testing.T) {
+
+	for _, how := range []string{"heartbeat", "probe"} {
+		t.Run(how, func(t *testing.T) {
+			setHealth(t, 0, 50*time.Millisecond, 20*time.Millisecond)
+			if how == "heartbeat" {
+				Heartbeat = 10 * time.Millisecond
+			} else {
+				// nothing listening
+				cl, _ := net.Listen("tcp", "127.0.0.1:0")
+				HealthProbe = "tcp://" + cl.Addr().String()
+				HealthProbeFailures = 2
+				cl.Close()
+			}
+
+			f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+				if n == 1 {
+					// hung: no heartbeats, ignores SIGABRT
+					p.onSignal = map[os.Signal]*ExitStatus{syscall.SIGKILL: {Code: -1, Signal: syscall.SIGKILL}}
+					return
+				}
+				p.exit <- &ExitStatus{Code: ExitFinished}
+			}}
+
+			s := testSupervisor(f)
+			if code := runSupervisor(t, s); code != 0 {
+				t.Fatalf("expected 0, got %d", code)
+			}
+
+			if len(f.procs) != 2 {
+				t.Fatalf("expected a restart, got %d starts", len(f.procs))
+			}
+			sigs := f.procs[0].Signals()
+			if len(sigs) != 2 || sigs[0] != syscall.SIGABRT || sigs[1] != syscall.SIGKILL {
+				t.Fatalf("expected ABRT, KILL, got %v", sigs)
+			}
+			if f.delays[0] != hungKillTimeout {
+				t.Fatalf("expected kill after %v, got %v", hungKillTimeout, f.delays)
+			}
+		})
+	}
+}
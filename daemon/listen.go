@@ -0,0 +1,138 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 15:30 (EDT)
+// Function: pass listening sockets from the supervisor to the child
+
+/*
+in main:
+    daemon.Inherit("tcp", ":443")
+    daemon.Ize()
+    ...
+    l, err := daemon.Listen("tcp", ":443")
+
+the supervisor opens the socket, and passes it to each child it starts.
+the socket stays open across restarts, so connections are queued,
+not refused, while a new child (perhaps a new binary, via SIGHUP) starts up.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const LISTENVAR = "_dlisten"
+
+type listenSpec struct {
+	network string
+	addr    string
+	fd      int
+	file    *os.File
+}
+
+var listenLock sync.Mutex
+var listenSpecs []*listenSpec
+var inherited map[string]*listenSpec
+var inheritOnce sync.Once
+
+// Inherit arranges for the supervisor to open a listening socket
+// and hand it to the child. must be called before Ize
+func Inherit(network, addr string) {
+	listenLock.Lock()
+	defer listenLock.Unlock()
+	listenSpecs = append(listenSpecs, &listenSpec{network: network, addr: addr})
+}
+
+// Listen returns the listener inherited from the supervisor, if there is one,
+// otherwise, a new one from net.Listen
+func Listen(network, addr string) (net.Listener, error) {
+
+	inheritOnce.Do(parseInherited)
+
+	listenLock.Lock()
+	ls := inherited[network+":"+addr]
+	delete(inherited, network+":"+addr)
+	listenLock.Unlock()
+
+	if ls == nil {
+		return net.Listen(network, addr)
+	}
+
+	f := os.NewFile(uintptr(ls.fd), network+":"+addr)
+	l, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cannot use inherited listener %s:%s: %v", network, addr, err)
+	}
+
+	return l, nil
+}
+
+// in the child: fd:network:addr;...
+func parseInherited() {
+
+	listenLock.Lock()
+	defer listenLock.Unlock()
+
+	inherited = make(map[string]*listenSpec)
+
+	for _, ent := range strings.Split(os.Getenv(LISTENVAR), ";") {
+		f := strings.SplitN(ent, ":", 3)
+		if len(f) != 3 {
+			continue
+		}
+		fd, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		inherited[f[1]+":"+f[2]] = &listenSpec{network: f[1], addr: f[2], fd: fd}
+	}
+
+	os.Unsetenv(LISTENVAR)
+}
+
+// in the supervisor: open the sockets
+// returns the files to pass to the child, starting at fd 3, and the env value
+func openListeners() ([]*os.File, string, error) {
+
+	listenLock.Lock()
+	defer listenLock.Unlock()
+
+	var files []*os.File
+	var env []string
+
+	for i, ls := range listenSpecs {
+		if ls.file == nil {
+			l, err := net.Listen(ls.network, ls.addr)
+			if err != nil {
+				return nil, "", err
+			}
+
+			switch l := l.(type) {
+			case *net.TCPListener:
+				ls.file, err = l.File()
+			case *net.UnixListener:
+				// we close our copy, the child still needs it
+				l.SetUnlinkOnClose(false)
+				ls.file, err = l.File()
+			default:
+				err = fmt.Errorf("unsupported network '%s'", ls.network)
+			}
+			l.Close()
+			if err != nil {
+				return nil, "", err
+			}
+		}
+
+		ls.fd = 3 + i
+		files = append(files, ls.file)
+		env = append(env, fmt.Sprintf("%d:%s:%s", ls.fd, ls.network, ls.addr))
+	}
+
+	return files, strings.Join(env, ";"), nil
+}
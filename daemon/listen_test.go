@@ -0,0 +1,118 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 15:10 (EDT)
+// Function: test passing listeners to the child
+
+package daemon
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const listenHelperVar = "_dtest_listen"
+
+// runs in a subprocess: accept one connection on each inherited listener
+func TestListenHelper(t *testing.T) {
+
+	specs := os.Getenv(listenHelperVar)
+	if specs == "" {
+		return
+	}
+
+	for _, spec := range strings.Split(specs, ";") {
+		f := strings.SplitN(spec, " ", 2)
+		l, err := Listen(f[0], f[1])
+		if err != nil {
+			os.Stdout.WriteString("error " + err.Error() + "\n")
+			os.Exit(1)
+		}
+		conn, err := l.Accept()
+		if err != nil {
+			os.Exit(1)
+		}
+		conn.Write([]byte(f[0] + "\n"))
+		conn.Close()
+	}
+	os.Exit(0)
+}
+
+func TestListenInherit(t *testing.T) {
+
+	sock := filepath.Join(t.TempDir(), "test.sock")
+
+	defer func(s []*listenSpec) { listenSpecs = s }(listenSpecs)
+	listenSpecs = nil
+
+	// port 0: a new listener in the child would get some other port
+	Inherit("tcp", "127.0.0.1:0")
+	Inherit("unix", sock)
+
+	files, env, err := openListeners()
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	// where did the tcp one end up?
+	l, err := net.FileListener(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	// files start at fd 3, same as the supervisor does it
+	cmd := exec.Command(os.Args[0], "-test.run=^TestListenHelper$")
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(), LISTENVAR+"="+env, listenHelperVar+"=tcp 127.0.0.1:0;unix "+sock)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start helper: %v", err)
+	}
+	defer cmd.Wait()
+
+	for _, a := range [][2]string{{"tcp", addr}, {"unix", sock}} {
+		conn, err := net.DialTimeout(a[0], a[1], 5*time.Second)
+		if err != nil {
+			cmd.Process.Kill()
+			t.Fatalf("dial %s: %v", a[0], err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		conn.Close()
+		if line != a[0]+"\n" {
+			cmd.Process.Kill()
+			t.Fatalf("%s: got %q", a[0], line)
+		}
+	}
+}
+
+func TestParseInherited(t *testing.T) {
+
+	t.Setenv(LISTENVAR, "3:tcp:[::1]:80;junk;x:tcp:a;4:unix:/tmp/s.sock")
+	defer func() { inherited = nil }()
+	parseInherited()
+
+	if len(inherited) != 2 {
+		t.Fatalf("expected 2, got %v", inherited)
+	}
+	if ls := inherited["tcp:[::1]:80"]; ls == nil || ls.fd != 3 {
+		t.Fatalf("wrong tcp listener: %+v", ls)
+	}
+	if ls := inherited["unix:/tmp/s.sock"]; ls == nil || ls.fd != 4 {
+		t.Fatalf("wrong unix listener: %+v", ls)
+	}
+	if os.Getenv(LISTENVAR) != "" {
+		t.Fatalf("env not cleared")
+	}
+}
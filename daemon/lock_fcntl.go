@@ -0,0 +1,40 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 11:10 (EDT)
+// Function: file locking, for systems without flock
+
+//go:build solaris
+
+package daemon
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// NB - fcntl locks belong to the process, not the file descriptor,
+// and are released when any descriptor for the file is closed
+
+var errLocked = errors.New("file is locked")
+
+// lock without waiting. returns errLocked if someone else has it
+func lockFile(f *os.File, exclusive bool) error {
+
+	lk := &syscall.Flock_t{Type: syscall.F_RDLCK, Whence: io.SeekStart}
+	if exclusive {
+		lk.Type = syscall.F_WRLCK
+	}
+
+	err := syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, lk)
+	if err == syscall.EAGAIN || err == syscall.EACCES {
+		return errLocked
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	lk := &syscall.Flock_t{Type: syscall.F_UNLCK, Whence: io.SeekStart}
+	return syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, lk)
+}
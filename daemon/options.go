@@ -0,0 +1,268 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 13:10 (EDT)
+// Function: daemon options, loadable from the config file
+
+/*
+in the config file:
+    daemon {
+        pidfile         /var/run/myprog.pid
+        logfile         /var/log/myprog.out
+        restart_delay   5
+        kill_timeout    1h
+        forward_signal  USR2
+        env             GOGC=200
+        user            nobody
+    }
+
+in main:
+    var cf struct {
+        ...
+        Daemon []*daemon.Options
+    }
+    accfg.Read(file, &cf)
+    daemon.IzeWith(cf.Daemon[0])
+
+or fill in an Options directly. zero values leave the package settings
+alone, and the package settings are not modified.
+durations are in seconds (or with a suffix: 1h = 1 hour, 1m = 1 month).
+forwarded signals are in addition to SIGINT, SIGTERM, SIGQUIT, SIGHUP.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Options configures Ize. the field tags are for accfg
+type Options struct {
+	Pidfile    string
+	PidfileDir string `name:"pidfile_dir"`
+	StatusFile string `name:"status_file"`
+	// stop a running instance, instead of refusing to start
+	Takeover bool
+
+	// save the children's output (rotated)
+	Logfile    string
+	LogMaxSize int `name:"log_max_size"`
+	LogKeep    int `name:"log_keep"`
+
+	// restart policy
+	RestartDelay    int     `name:"restart_delay" convert:"duration"`
+	RestartMaxDelay int     `name:"restart_max_delay" convert:"duration"`
+	RestartBackoff  float64 `name:"restart_backoff"`
+	MaxRestarts     int     `name:"max_restarts"`
+	RestartWindow   int     `name:"restart_window" convert:"duration"`
+	KillTimeout     int     `name:"kill_timeout" convert:"duration"`
+
+	// complain if a child uses more memory than this (MB)
+	MemoryWarn int `name:"memory_warn"`
+
+	// more signals to pass on to the children, by name (HUP or SIGHUP)
+	Signals []string `name:"forward_signal"`
+	// NAME=value, added to the children's environment
+	Env []string `name:"env"`
+
+	User    string
+	Group   string
+	Chroot  string
+	Chdir   string
+	Workers int
+	Control bool
+	// stay in the foreground, without supervision
+	Foreground bool
+}
+
+var defaultSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP}
+
+var signalNames = map[string]syscall.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"TERM":  syscall.SIGTERM,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"TTIN":  syscall.SIGTTIN,
+	"TTOU":  syscall.SIGTTOU,
+	"WINCH": syscall.SIGWINCH,
+}
+
+// what Ize runs with: the package settings, with the options applied
+type config struct {
+	pidfile     string
+	pidfileDir  string
+	statusFile  string
+	logfile     string
+	logMaxSize  int64
+	logKeep     int
+	restart     *RestartPolicy
+	killTimeout time.Duration
+	memoryWarn  int64
+	forward     []os.Signal // nil = defaultSignals
+	env         []string    // added to ours
+	user        string
+	group       string
+	chroot      string
+	chdir       string
+	workers     int
+	control     bool
+	foreground  bool
+	takeover    bool
+}
+
+// the package settings
+func defaultConfig() *config {
+	return &config{
+		pidfile:     Pidfile,
+		pidfileDir:  PidfileDir,
+		statusFile:  StatusFile,
+		logfile:     Logfile,
+		logMaxSize:  LogMaxSize,
+		logKeep:     LogKeep,
+		restart:     Restart,
+		killTimeout: KillTimeout,
+		memoryWarn:  MemoryWarn,
+		user:        User,
+		group:       Group,
+		chroot:      Chroot,
+		chdir:       Chdir,
+		workers:     Workers,
+		control:     Control,
+		foreground:  Foreground,
+		takeover:    Takeover,
+	}
+}
+
+// the package settings, with the options applied
+func (o *Options) config() (*config, error) {
+
+	cf := defaultConfig()
+
+	if o == nil {
+		return cf, nil
+	}
+
+	var sigs []os.Signal
+	for _, name := range o.Signals {
+		n, err := parseSignal(name)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, n)
+	}
+	if sigs != nil {
+		cf.forward = addSignals(defaultSignals, sigs)
+	}
+
+	for _, e := range o.Env {
+		if !strings.Contains(e, "=") {
+			return nil, fmt.Errorf("invalid env '%s' (expected NAME=value)", e)
+		}
+	}
+	cf.env = o.Env
+
+	setString(&cf.pidfile, o.Pidfile)
+	setString(&cf.pidfileDir, o.PidfileDir)
+	setString(&cf.statusFile, o.StatusFile)
+	setString(&cf.logfile, o.Logfile)
+	setString(&cf.user, o.User)
+	setString(&cf.group, o.Group)
+	setString(&cf.chroot, o.Chroot)
+	setString(&cf.chdir, o.Chdir)
+
+	if o.LogMaxSize != 0 {
+		cf.logMaxSize = int64(o.LogMaxSize)
+	}
+	if o.LogKeep != 0 {
+		cf.logKeep = o.LogKeep
+	}
+	if o.KillTimeout != 0 {
+		cf.killTimeout = seconds(o.KillTimeout)
+	}
+	if o.MemoryWarn != 0 {
+		cf.memoryWarn = int64(o.MemoryWarn) << 20
+	}
+	if o.Workers != 0 {
+		cf.workers = o.Workers
+	}
+	if o.Control {
+		cf.control = true
+	}
+	if o.Foreground {
+		cf.foreground = true
+	}
+	if o.Takeover {
+		cf.takeover = true
+	}
+
+	// don't scribble on the shared default
+	r := *Restart
+	if o.RestartDelay != 0 {
+		r.Delay = seconds(o.RestartDelay)
+	}
+	if o.RestartMaxDelay != 0 {
+		r.MaxDelay = seconds(o.RestartMaxDelay)
+	}
+	if o.RestartBackoff != 0 {
+		r.Backoff = o.RestartBackoff
+	}
+	if o.MaxRestarts != 0 {
+		r.MaxRestarts = o.MaxRestarts
+	}
+	if o.RestartWindow != 0 {
+		r.Window = seconds(o.RestartWindow)
+	}
+	cf.restart = &r
+
+	return cf, nil
+}
+
+func parseSignal(name string) (syscall.Signal, error) {
+
+	n, ok := signalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("invalid signal '%s'", name)
+	}
+	return n, nil
+}
+
+// our env, with the extra settings replacing any existing ones
+func mergeEnv(env []string, extra []string) []string {
+
+	if len(extra) == 0 {
+		return env
+	}
+
+	var res []string
+
+	for _, e := range env {
+		name := e[:strings.IndexByte(e+"=", '=')]
+		replaced := false
+		for _, x := range extra {
+			if hasEnvName(x, name) {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			res = append(res, e)
+		}
+	}
+
+	return append(res, extra...)
+}
+
+func setString(v *string, s string) {
+	if s != "" {
+		*v = s
+	}
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 13:40 (EDT)
+// Function: test options
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jaw0/acgo/accfg"
+)
+
+const testConfig = `
+pidfile         /tmp/test.pid
+restart_delay   10
+restart_backoff 1.5
+kill_timeout    1h
+forward_signal  SIGHUP
+forward_signal  usr1
+env             FOO=bar
+user            nobody
+workers         3
+`
+
+func TestOptions(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "test.conf")
+	os.WriteFile(file, []byte(testConfig), 0644)
+
+	var opts Options
+	if err := accfg.Read(file, &opts); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	orig := Restart.Delay
+	pidfile, user, kill, workers := Pidfile, User, KillTimeout, Workers
+
+	cf, err := opts.config()
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+
+	if cf.pidfile != "/tmp/test.pid" || cf.user != "nobody" || cf.workers != 3 {
+		t.Fatalf("wrong settings: %q %q %d", cf.pidfile, cf.user, cf.workers)
+	}
+	if cf.killTimeout != time.Hour {
+		t.Fatalf("wrong kill timeout: %v", cf.killTimeout)
+	}
+	if cf.restart.Delay != 10*time.Second || cf.restart.Backoff != 1.5 || cf.restart.MaxDelay == 0 {
+		t.Fatalf("wrong restart policy: %+v", cf.restart)
+	}
+
+	// the package settings are left alone
+	if Restart.Delay != orig || Pidfile != pidfile || User != user || KillTimeout != kill || Workers != workers {
+		t.Fatalf("package settings were modified")
+	}
+
+	// the usual signals, plus the extra ones
+	s := newSupervisor("/bin/test", cf)
+	for _, n := range []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP, syscall.SIGUSR1} {
+		if !hasSignal(s.Forward, n) {
+			t.Fatalf("%v not forwarded: %v", n, s.Forward)
+		}
+	}
+
+	// not accumulated
+	cf, _ = opts.config()
+	s = newSupervisor("/bin/test", cf)
+	if n := strings.Count(strings.Join(s.Env, " "), "FOO="); n != 1 {
+		t.Fatalf("wrong env: %v", s.Env)
+	}
+
+	env := mergeEnv([]string{"HOME=/", "FOO=x"}, cf.env)
+	if len(env) != 2 || env[0] != "HOME=/" || env[1] != "FOO=bar" {
+		t.Fatalf("wrong env: %v", env)
+	}
+}
+
+func hasSignal(sigs []os.Signal, n os.Signal) bool {
+	for _, s := range sigs {
+		if s == n {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOptionsInvalid(t *testing.T) {
+
+	if _, err := (&Options{Signals: []string{"BOGUS"}}).config(); err == nil {
+		t.Fatalf("expected signal error")
+	}
+	if _, err := (&Options{Env: []string{"FOO"}}).config(); err == nil {
+		t.Fatalf("expected env error")
+	}
+}
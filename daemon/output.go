@@ -0,0 +1,234 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 16:45 (EDT)
+// Function: capture child output
+
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// if set, the child's stdout+stderr are written here, instead of discarded
+var Logfile = ""
+
+// rotate the log file when it reaches LogMaxSize, keep LogKeep old ones
+var LogMaxSize int64 = 10 * 1024 * 1024
+var LogKeep = 5
+
+// number of lines of output to include in crash reports
+var LogTail = 50
+
+type logWriter struct {
+	lock    sync.Mutex
+	file    string
+	out     io.Writer // instead of file
+	maxSize int64
+	keep    int
+	f       *os.File
+	size    int64
+	tail    *tailBuf
+	failed  bool
+}
+
+func newLogWriter(file string, maxSize int64, keep int, ntail int) *logWriter {
+	return &logWriter{
+		file:    file,
+		maxSize: maxSize,
+		keep:    keep,
+		tail:    &tailBuf{max: ntail},
+	}
+}
+
+// write to out, instead of a file
+func newOutputWriter(out io.Writer, ntail int) *logWriter {
+	return &logWriter{
+		out:  out,
+		tail: &tailBuf{max: ntail},
+	}
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.tail.Write(b)
+
+	var err error
+	if w.out != nil {
+		_, err = w.out.Write(b)
+	} else {
+		err = w.writeFile(b)
+	}
+	w.problem(err)
+
+	// never fail. that would stop the copy from the pipe,
+	// and the child would die of SIGPIPE. drop the output instead
+	return len(b), nil
+}
+
+func (w *logWriter) writeFile(b []byte) error {
+
+	if w.f == nil {
+		err := w.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := w.f.Write(b)
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if w.maxSize > 0 && w.size >= w.maxSize {
+		return w.rotate()
+	}
+
+	return nil
+}
+
+// complain once, not on every write
+func (w *logWriter) problem(err error) {
+
+	switch {
+	case err != nil && !w.failed:
+		dl.Problem("cannot write output log: %v; discarding output", err)
+		w.failed = true
+	case err == nil && w.failed:
+		dl.Verbose("output log is working again")
+		w.failed = false
+	}
+}
+
+// Reopen closes and reopens the log file (after an external log rotator moved it)
+func (w *logWriter) Reopen() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.out != nil {
+		return nil
+	}
+	if w.f != nil {
+		w.f.Close()
+		w.f = nil
+	}
+	return w.open()
+}
+
+// Tail returns the recent output
+func (w *logWriter) Tail() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.tail.String()
+}
+
+// ResetTail discards the recent output
+func (w *logWriter) ResetTail() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.tail.Reset()
+}
+
+func (w *logWriter) open() error {
+
+	f, err := os.OpenFile(w.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	st, err := f.Stat()
+	if err == nil {
+		w.size = st.Size()
+	}
+
+	w.f = f
+	return nil
+}
+
+// file.log => file.log.1 => file.log.2 ...
+func (w *logWriter) rotate() error {
+
+	w.f.Close()
+	w.f = nil
+
+	if w.keep <= 0 {
+		os.Remove(w.file)
+	} else {
+		for i := w.keep - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", w.file, i), fmt.Sprintf("%s.%d", w.file, i+1))
+		}
+		os.Rename(w.file, w.file+".1")
+	}
+
+	return w.open()
+}
+
+const tailMaxLine = 4096
+
+// the most recent lines
+type tailBuf struct {
+	max   int
+	lines []string
+	part  string
+}
+
+func (t *tailBuf) Write(b []byte) {
+
+	if t.max <= 0 {
+		return
+	}
+
+	s := t.part + string(b)
+	lines := strings.Split(s, "\n")
+	t.part = lines[len(lines)-1]
+	if len(t.part) > tailMaxLine {
+		t.part = t.part[len(t.part)-tailMaxLine:]
+	}
+	t.lines = append(t.lines, lines[:len(lines)-1]...)
+
+	if len(t.lines) > t.max {
+		t.lines = append([]string{}, t.lines[len(t.lines)-t.max:]...)
+	}
+}
+
+func (t *tailBuf) String() string {
+	s := strings.Join(t.lines, "\n")
+	if t.part != "" {
+		if s != "" {
+			s += "\n"
+		}
+		s += t.part
+	}
+	return s
+}
+
+func (t *tailBuf) Reset() {
+	t.lines = nil
+	t.part = ""
+}
+
+// connect the child's stdout+stderr to the log
+// returns the file for the child, and a channel that closes when the output is done
+func (w *logWriter) pipe() (*os.File, chan struct{}, error) {
+
+	r, wr, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(w, r)
+		r.Close()
+		close(done)
+	}()
+
+	return wr, done, nil
+}
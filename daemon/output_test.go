@@ -0,0 +1,105 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 11:50 (EDT)
+// Function: test output capture
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailBuf(t *testing.T) {
+
+	tests := []struct {
+		max    int
+		writes []string
+		expect string
+	}{
+		{3, []string{"a\nb\n"}, "a\nb"},
+		{3, []string{"a\nb\nc\nd\ne\n"}, "c\nd\ne"},
+		{3, []string{"a\nb", "c\nd\n", "e"}, "a\nbc\nd\ne"},
+		{0, []string{"a\nb\n"}, ""},
+		{2, []string{strings.Repeat("x", tailMaxLine+10)}, strings.Repeat("x", tailMaxLine)},
+	}
+
+	for _, tt := range tests {
+		tb := &tailBuf{max: tt.max}
+		for _, w := range tt.writes {
+			tb.Write([]byte(w))
+		}
+		if got := tb.String(); got != tt.expect {
+			t.Errorf("%q: expected %q, got %q", tt.writes, tt.expect, got)
+		}
+	}
+}
+
+func TestLogRotate(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "test.log")
+	w := newLogWriter(file, 100, 2, 5)
+
+	line := []byte(strings.Repeat("x", 49) + "\n")
+	for i := 0; i < 9; i++ {
+		if n, err := w.Write(line); n != len(line) || err != nil {
+			t.Fatalf("write: %d, %v", n, err)
+		}
+	}
+
+	for _, f := range []string{file, file + ".1", file + ".2"} {
+		if _, err := os.Stat(f); err != nil {
+			t.Fatalf("expected %s: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(file + ".3"); err == nil {
+		t.Fatalf("kept too many")
+	}
+
+	st, _ := os.Stat(file)
+	if st.Size() != 50 {
+		t.Fatalf("expected 50 bytes in current log, got %d", st.Size())
+	}
+}
+
+func TestLogUnwritable(t *testing.T) {
+
+	w := newLogWriter(filepath.Join(t.TempDir(), "nonexistent", "test.log"), 0, 0, 5)
+
+	wr, done, err := w.pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// more than fits in a pipe. the output is dropped, but keeps flowing
+	finished := make(chan error)
+	go func() {
+		buf := []byte(strings.Repeat("x", 1023) + "\n")
+		for i := 0; i < 1000; i++ {
+			if _, err := wr.Write(buf); err != nil {
+				finished <- err
+				return
+			}
+		}
+		finished <- nil
+	}()
+
+	select {
+	case err := <-finished:
+		if err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("output stopped flowing")
+	}
+
+	wr.Close()
+	<-done
+
+	if w.Tail() == "" {
+		t.Fatalf("expected the tail to be kept")
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 16:10 (EDT)
+// Function: pid files
+
+/*
+the pid file is locked (flock, or fcntl where there is no flock)
+for as long as the daemon runs.
+
+    12345
+    # /path/to/prog args...
+
+only one instance may run at a time. with Takeover set, a new
+instance asks the running one to stop, and waits for it.
+*/
+
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RunningError is returned when another instance is running
+type RunningError struct {
+	File string
+	Pid  int
+}
+
+func (e *RunningError) Error() string {
+	return fmt.Sprintf("already running (pid %d, per %s)", e.Pid, e.File)
+}
+
+// stop an already running instance, instead of refusing to start
+var Takeover = false
+
+// how long to wait for it to stop
+var TakeoverTimeout = 90 * time.Second
+
+// the locked pid file, held open until exit
+var pidLock *os.File
+
+// SavePidFile atomically writes and locks the pid file.
+// returns a *RunningError if another instance is running
+func SavePidFile(file string) error {
+
+	// hold the lock on the current pid file while we replace it,
+	// so two instances starting at once cannot both succeed
+	cur, err := lockPidFile(file)
+	if err != nil {
+		return err
+	}
+	if cur != nil {
+		defer cur.Close()
+	}
+
+	f, err := ioutil.TempFile(path.Dir(file), "."+path.Base(file)+".")
+	if err != nil {
+		return err
+	}
+
+	err = lockFile(f, true)
+	if err == nil {
+		err = writePidFile(f)
+	}
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if pidLock != nil {
+		pidLock.Close()
+	}
+	pidLock = f
+	return nil
+}
+
+// can the pid file be saved? the supervisor saves it, once we are in
+// the background, so check now, while someone is watching
+func checkPidFileWritable(file string) error {
+
+	_, err := os.Stat(file)
+	existed := err == nil
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	if !existed {
+		os.Remove(file)
+	}
+
+	// it is replaced by renaming a new one into place
+	t, err := ioutil.TempFile(path.Dir(file), "."+path.Base(file)+".")
+	if err != nil {
+		return err
+	}
+	t.Close()
+	os.Remove(t.Name())
+	return nil
+}
+
+// open + lock the existing pid file (or a new empty one).
+// returns nil if we already hold the lock
+func lockPidFile(file string) (*os.File, error) {
+
+	for {
+		f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		pid, prog := readPidFile(f)
+
+		err = lockFile(f, true)
+		if err == errLocked {
+			f.Close()
+			if pid == os.Getpid() {
+				// we already have it
+				return nil, nil
+			}
+			return nil, &RunningError{File: file, Pid: pid}
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		if !samePath(f, file) {
+			// replaced while we were locking it. try again
+			f.Close()
+			continue
+		}
+
+		// not locked. but perhaps an older version that does not lock?
+		if pid > 0 && pid != os.Getpid() && pidAlive(pid) && sameProgram(pid, prog) {
+			f.Close()
+			return nil, &RunningError{File: file, Pid: pid}
+		}
+
+		return f, nil
+	}
+}
+
+// is f still the file at path?
+func samePath(f *os.File, file string) bool {
+
+	fst, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pst, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fst, pst)
+}
+
+func writePidFile(f *os.File) error {
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "%d\n", os.Getpid())
+
+	prog, err := os.Executable()
+	if err == nil {
+		fmt.Fprintf(w, "# %s", prog)
+		for _, arg := range os.Args[1:] {
+			w.WriteString(" ")
+			w.WriteString(arg)
+		}
+		w.WriteString("\n")
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func RemovePidFile(file string) {
+	os.Remove(file)
+
+	if pidLock != nil {
+		pidLock.Close()
+		pidLock = nil
+	}
+}
+
+// CheckPidFile returns a *RunningError if another instance is running.
+// a stale pid file is not an error
+func CheckPidFile(file string) error {
+
+	if pidLock != nil && samePath(pidLock, file) {
+		// ours. (and with fcntl locks, closing it would unlock it)
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pid, prog := readPidFile(f)
+	if pid == os.Getpid() {
+		return nil
+	}
+
+	err = lockFile(f, false)
+	if err == errLocked {
+		return &RunningError{File: file, Pid: pid}
+	}
+	if err == nil {
+		unlockFile(f)
+	}
+
+	// not locked. but perhaps an older version that does not lock?
+	if pid > 0 && pidAlive(pid) && sameProgram(pid, prog) {
+		return &RunningError{File: file, Pid: pid}
+	}
+
+	dl.Debug("stale pid file %s (pid %d)", file, pid)
+	return nil
+}
+
+// returns the pid, and the program from the comment line
+func readPidFile(f *os.File) (int, string) {
+
+	var pid int
+	var prog string
+
+	scan := bufio.NewScanner(f)
+	if scan.Scan() {
+		pid, _ = strconv.Atoi(strings.TrimSpace(scan.Text()))
+	}
+	if scan.Scan() {
+		line := scan.Text()
+		if strings.HasPrefix(line, "# ") {
+			prog = strings.Fields(line[2:] + " ")[0]
+		}
+	}
+
+	return pid, prog
+}
+
+func pidAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// is pid running the same executable as us?
+func sameProgram(pid int, prog string) bool {
+
+	me, err := os.Executable()
+	if err != nil {
+		return true
+	}
+
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err == nil {
+		// the binary may have been replaced since it started
+		exe = strings.TrimSuffix(exe, " (deleted)")
+		return exe == me
+	}
+
+	// no /proc, or not permitted. go by what the pid file says
+	return prog == "" || prog == me
+}
+
+// ClaimPidFile checks that no other instance is running.
+// if one is, and Takeover is set, it is stopped
+func ClaimPidFile(file string) error {
+	return claimPidFile(file, Takeover)
+}
+
+func claimPidFile(file string, takeover bool) error {
+
+	err := CheckPidFile(file)
+	re, ok := err.(*RunningError)
+	if !ok || !takeover || re.Pid <= 0 {
+		return err
+	}
+
+	dl.Verbose("stopping running instance (pid %d)", re.Pid)
+	err = syscall.Kill(re.Pid, syscall.SIGTERM)
+	if err != nil {
+		return fmt.Errorf("cannot stop running instance (pid %d): %v", re.Pid, err)
+	}
+
+	// wait for it to release the pid file
+	for end := time.Now().Add(TakeoverTimeout); time.Now().Before(end); {
+		time.Sleep(100 * time.Millisecond)
+		err = CheckPidFile(file)
+		if _, ok := err.(*RunningError); !ok {
+			return err
+		}
+	}
+
+	return fmt.Errorf("running instance (pid %d) did not stop", re.Pid)
+}
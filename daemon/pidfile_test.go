@@ -0,0 +1,144 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 16:40 (EDT)
+// Function: test pid files + takeover
+
+package daemon
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const pidHelperVar = "_dtest_pidfile"
+
+// runs in a subprocess: hold the pid file until told to stop
+func TestPidHelper(t *testing.T) {
+
+	file := os.Getenv(pidHelperVar)
+	if file == "" {
+		return
+	}
+
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGTERM)
+
+	if err := SavePidFile(file); err != nil {
+		os.Stdout.WriteString("error " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	os.Stdout.WriteString("ready\n")
+
+	<-sigchan
+	RemovePidFile(file)
+	os.Exit(0)
+}
+
+func startPidHelper(t *testing.T, file string) *exec.Cmd {
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestPidHelper$")
+	cmd.Env = append(os.Environ(), pidHelperVar+"="+file)
+	out, _ := cmd.StdoutPipe()
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start helper: %v", err)
+	}
+
+	line, _ := bufio.NewReader(out).ReadString('\n')
+	if line != "ready\n" {
+		cmd.Process.Kill()
+		t.Fatalf("helper: %q", line)
+	}
+	return cmd
+}
+
+func TestPidFileRunning(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "test.pid")
+	cmd := startPidHelper(t, file)
+	defer func() {
+		cmd.Process.Signal(syscall.SIGTERM)
+		cmd.Wait()
+	}()
+
+	err := SavePidFile(file)
+	re, ok := err.(*RunningError)
+	if !ok || re.Pid != cmd.Process.Pid {
+		t.Fatalf("expected RunningError, got %v", err)
+	}
+
+	if _, ok := ClaimPidFile(file).(*RunningError); !ok {
+		t.Fatalf("expected RunningError")
+	}
+}
+
+func TestPidFileTakeover(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "test.pid")
+	cmd := startPidHelper(t, file)
+
+	defer func(t bool) { Takeover = t }(Takeover)
+	Takeover = true
+
+	done := make(chan error)
+	go func() { done <- cmd.Wait() }()
+
+	if err := ClaimPidFile(file); err != nil {
+		t.Fatalf("takeover: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("helper: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		t.Fatalf("helper did not exit")
+	}
+
+	if err := SavePidFile(file); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	RemovePidFile(file)
+}
+
+func TestPidFileWritable(t *testing.T) {
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.pid")
+
+	if err := checkPidFileWritable(file); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if ents, _ := os.ReadDir(dir); len(ents) != 0 {
+		t.Fatalf("check left files behind: %v", ents)
+	}
+
+	// an existing one is left alone
+	os.WriteFile(file, []byte("123\n"), 0644)
+	if err := checkPidFileWritable(file); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if b, _ := os.ReadFile(file); string(b) != "123\n" {
+		t.Fatalf("pid file modified: %q", b)
+	}
+
+	if err := checkPidFileWritable(filepath.Join(dir, "nonesuch", "test.pid")); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if os.Geteuid() != 0 {
+		os.Chmod(dir, 0555)
+		defer os.Chmod(dir, 0755)
+		if err := checkPidFileWritable(filepath.Join(dir, "other.pid")); err == nil {
+			t.Fatalf("expected permission error")
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 22:40 (EDT)
+// Function: multiple worker children
+
+/*
+in main:
+    daemon.Workers = 4
+    daemon.Inherit("tcp", ":80")
+    daemon.Ize()
+    log.Printf("worker %d", daemon.WorkerIndex())
+
+each worker is restarted independently.
+signals are passed on to all workers.
+SIGTTIN adds a worker, SIGTTOU removes one.
+*/
+
+package daemon
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const WORKERVAR = "_dworker"
+
+// number of worker children to run. 0 = a single (non-worker) child
+var Workers = 0
+
+// WorkerIndex returns the index of this worker (0 ... Workers-1),
+// or -1 if we are not a worker
+func WorkerIndex() int {
+
+	i, err := strconv.Atoi(os.Getenv(WORKERVAR))
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+// change the number of workers
+func (s *Supervisor) scale(n int) {
+
+	if n < 1 || s.stopping {
+		return
+	}
+
+	dl.Verbose("scaling workers %d -> %d", s.running(), n)
+	s.Workers = n
+
+	for s.running() < n {
+		s.addChild()
+	}
+
+	// retire the highest numbered workers
+	for s.running() > n {
+		var last *child
+		for _, c := range s.children {
+			if !c.retire && (last == nil || c.index > last.index) {
+				last = c
+			}
+		}
+
+		last.retire = true
+		if last.proc == nil {
+			s.removeChild(last)
+			continue
+		}
+		last.proc.Signal(syscall.SIGTERM)
+		s.startKiller(last, s.KillTimeout)
+	}
+}
+
+// number of children, not counting those on their way out
+func (s *Supervisor) running() int {
+	n := 0
+	for _, c := range s.children {
+		if !c.retire {
+			n++
+		}
+	}
+	return n
+}
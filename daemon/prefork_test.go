@@ -0,0 +1,106 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 23:10 (EDT)
+// Function: test multiple workers
+
+package daemon
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSupervisorWorkers(t *testing.T) {
+
+	started := make(chan *fakeProc, 10)
+	var lock sync.Mutex
+	workers := make(map[*fakeProc]string)
+	index := func(p *fakeProc) string {
+		lock.Lock()
+		defer lock.Unlock()
+		return workers[p]
+	}
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		p.onSignal = map[os.Signal]*ExitStatus{syscall.SIGTERM: {Code: ExitFinished}}
+		started <- p
+	}}
+
+	s := testSupervisor(f)
+	s.Workers = 2
+	spawn := s.Spawn
+	s.Spawn = func(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		p, err := spawn(prog, args, attr)
+		for _, e := range attr.Env {
+			if strings.HasPrefix(e, WORKERVAR+"=") {
+				workers[p.(*fakeProc)] = e[len(WORKERVAR)+1:]
+			}
+		}
+		return p, err
+	}
+
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+
+	next := func() *fakeProc {
+		select {
+		case p := <-started:
+			return p
+		case <-time.After(10 * time.Second):
+			t.Fatalf("worker did not start")
+		}
+		return nil
+	}
+
+	w0 := next()
+	w1 := next()
+	if index(w0) != "0" || index(w1) != "1" {
+		t.Fatalf("bad worker index %q %q", index(w0), index(w1))
+	}
+
+	// crash one, it gets restarted, as the same worker
+	w0.exit <- &ExitStatus{Code: 3}
+	w0 = next()
+	if index(w0) != "0" {
+		t.Fatalf("expected worker 0 restarted, got %q", index(w0))
+	}
+
+	// scale up + down
+	s.Signals <- syscall.SIGTTIN
+	w2 := next()
+	if index(w2) != "2" {
+		t.Fatalf("expected worker 2, got %q", index(w2))
+	}
+	s.Signals <- syscall.SIGTTOU
+
+	s.Signals <- syscall.SIGHUP
+	s.Signals <- syscall.SIGTERM
+
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Fatalf("expected 0, got %d", code)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("supervisor did not stop")
+	}
+
+	if sigs := w2.Signals(); len(sigs) != 1 || sigs[0] != syscall.SIGTERM {
+		t.Fatalf("expected retired worker to get TERM only, got %v", sigs)
+	}
+	for _, p := range []*fakeProc{w0, w1} {
+		sigs := p.Signals()
+		if len(sigs) != 2 || sigs[0] != syscall.SIGHUP || sigs[1] != syscall.SIGTERM {
+			t.Fatalf("expected HUP, TERM, got %v", sigs)
+		}
+	}
+	if len(f.procs) != 4 {
+		t.Fatalf("expected 4 starts, got %d", len(f.procs))
+	}
+}
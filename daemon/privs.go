@@ -0,0 +1,247 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 19:05 (EDT)
+// Function: drop privileges
+
+/*
+the supervisor keeps its privileges (to write the pid file, open
+listening sockets on low ports, etc). the child switches to
+the specified user/group, etc, before the main program runs.
+the settings are checked at startup, before switching to the background.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// user name or uid to run the child as
+var User = ""
+
+// group name or gid. default: the user's primary group. (may be set without User)
+var Group = ""
+
+// supplementary groups. default: the user's groups
+var Groups []string
+
+// file creation mask. -1 = leave unchanged
+var Umask = -1
+
+// chroot to this directory, then chdir to Chdir (or "/")
+var Chroot = ""
+var Chdir = ""
+
+// resource limits. 0 = leave unchanged
+var MaxOpenFiles uint64 = 0
+
+// -1 = leave unchanged
+var MaxCoreSize int64 = -1
+
+// DropPrivileges applies the above settings to the current process.
+// it is called automatically in the child by Ize
+func DropPrivileges() error {
+	return dropPrivileges(defaultConfig())
+}
+
+func dropPrivileges(cf *config) error {
+
+	// while we still can
+	err := setRlimits()
+	if err != nil {
+		return err
+	}
+
+	uid, gid, groups, err := lookupCreds(cf.user, cf.group)
+	if err != nil {
+		return err
+	}
+
+	chdir := cf.chdir
+	if cf.chroot != "" {
+		err = syscall.Chroot(cf.chroot)
+		if err != nil {
+			return fmt.Errorf("cannot chroot to %s: %v", cf.chroot, err)
+		}
+		if chdir == "" {
+			chdir = "/"
+		}
+	}
+
+	if chdir != "" {
+		err = os.Chdir(chdir)
+		if err != nil {
+			return fmt.Errorf("cannot chdir to %s: %v", chdir, err)
+		}
+	}
+
+	if Umask != -1 {
+		syscall.Umask(Umask)
+	}
+
+	// order matters. groups first, while we are still root
+	if gid != -1 {
+		err = syscall.Setgroups(groups)
+		if err != nil {
+			return fmt.Errorf("cannot set groups: %v", err)
+		}
+		err = syscall.Setgid(gid)
+		if err != nil {
+			return fmt.Errorf("cannot set gid %d: %v", gid, err)
+		}
+	}
+	if uid != -1 {
+		err = syscall.Setuid(uid)
+		if err != nil {
+			return fmt.Errorf("cannot set uid %d: %v", uid, err)
+		}
+	}
+
+	return nil
+}
+
+// check the settings, in the launcher, while someone is watching.
+// (the child would only fail, and be restarted, over and over)
+func checkPrivileges(cf *config) error {
+
+	uid, gid, _, err := lookupCreds(cf.user, cf.group)
+	if err != nil {
+		return err
+	}
+
+	root := os.Geteuid() == 0
+	if uid != -1 && uid != os.Geteuid() && !root {
+		return fmt.Errorf("cannot switch to user '%s': not root", cf.user)
+	}
+	if gid != -1 && gid != os.Getegid() && !root {
+		return fmt.Errorf("cannot switch to group %d: not root", gid)
+	}
+
+	chdir := cf.chdir
+	if cf.chroot != "" {
+		if !root {
+			return fmt.Errorf("cannot chroot to %s: not root", cf.chroot)
+		}
+		if err := checkDir(cf.chroot); err != nil {
+			return fmt.Errorf("cannot chroot to %s: %v", cf.chroot, err)
+		}
+		if chdir != "" {
+			chdir = filepath.Join(cf.chroot, chdir)
+		}
+	}
+	if chdir != "" {
+		if err := checkDir(chdir); err != nil {
+			return fmt.Errorf("cannot chdir to %s: %v", cf.chdir, err)
+		}
+	}
+
+	return nil
+}
+
+func checkDir(dir string) error {
+
+	st, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !st.IsDir() {
+		return fmt.Errorf("not a directory")
+	}
+	return nil
+}
+
+// uid, gid = -1 if no change
+func lookupCreds(name string, group string) (int, int, []int, error) {
+
+	uid, gid := -1, -1
+	var u *user.User
+	var err error
+
+	if name != "" {
+		u, err = user.Lookup(name)
+		if err != nil {
+			u, err = user.LookupId(name)
+		}
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("unknown user '%s'", name)
+		}
+
+		uid, _ = strconv.Atoi(u.Uid)
+		gid, _ = strconv.Atoi(u.Gid)
+	}
+
+	if group != "" {
+		gid, err = lookupGroup(group)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	if gid == -1 {
+		return -1, -1, nil, nil
+	}
+
+	var groups []int
+	switch {
+	case Groups != nil:
+		for _, g := range Groups {
+			id, err := lookupGroup(g)
+			if err != nil {
+				return 0, 0, nil, err
+			}
+			groups = append(groups, id)
+		}
+	case u != nil:
+		gids, _ := u.GroupIds()
+		for _, g := range gids {
+			id, err := strconv.Atoi(g)
+			if err == nil {
+				groups = append(groups, id)
+			}
+		}
+	default:
+		// just the group
+		groups = []int{gid}
+	}
+
+	return uid, gid, groups, nil
+}
+
+func lookupGroup(name string) (int, error) {
+
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		g, err = user.LookupGroupId(name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unknown group '%s'", name)
+	}
+
+	return strconv.Atoi(g.Gid)
+}
+
+func setRlimits() error {
+
+	if MaxOpenFiles != 0 {
+		lim := mkRlimit(MaxOpenFiles)
+		err := syscall.Setrlimit(syscall.RLIMIT_NOFILE, lim)
+		if err != nil {
+			return fmt.Errorf("cannot set open file limit: %v", err)
+		}
+	}
+
+	if MaxCoreSize != -1 {
+		lim := mkRlimit(uint64(MaxCoreSize))
+		err := syscall.Setrlimit(syscall.RLIMIT_CORE, lim)
+		if err != nil {
+			return fmt.Errorf("cannot set core size limit: %v", err)
+		}
+	}
+
+	return nil
+}
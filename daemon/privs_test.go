@@ -0,0 +1,80 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 16:05 (EDT)
+// Function: test privilege settings
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestLookupCreds(t *testing.T) {
+
+	uid, gid, _, err := lookupCreds("", "")
+	if err != nil || uid != -1 || gid != -1 {
+		t.Fatalf("no change: got %d %d %v", uid, gid, err)
+	}
+
+	// a group, without a user
+	me := strconv.Itoa(os.Getgid())
+	uid, gid, groups, err := lookupCreds("", me)
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if uid != -1 || gid != os.Getgid() || len(groups) != 1 || groups[0] != gid {
+		t.Fatalf("group only: got %d %d %v", uid, gid, groups)
+	}
+
+	uid, _, _, err = lookupCreds(strconv.Itoa(os.Getuid()), "")
+	if err != nil || uid != os.Getuid() {
+		t.Fatalf("user: got %d %v", uid, err)
+	}
+
+	if _, _, _, err = lookupCreds("no-such-user-here", ""); err == nil {
+		t.Fatalf("expected unknown user")
+	}
+	if _, _, _, err = lookupCreds("", "no-such-group-here"); err == nil {
+		t.Fatalf("expected unknown group")
+	}
+}
+
+func TestCheckPrivileges(t *testing.T) {
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0644)
+
+	good := []*config{
+		{},
+		{user: strconv.Itoa(os.Getuid()), group: strconv.Itoa(os.Getgid())},
+		{chdir: dir},
+	}
+	bad := []*config{
+		{user: "no-such-user-here"},
+		{group: "no-such-group-here"},
+		{chdir: filepath.Join(dir, "nonesuch")},
+		{chdir: file},
+		{chroot: filepath.Join(dir, "nonesuch")},
+	}
+	if os.Geteuid() == 0 {
+		good = append(good, &config{chroot: dir, chdir: "/"})
+		bad = append(bad, &config{chroot: dir, chdir: "nonesuch"})
+	} else {
+		bad = append(bad, &config{user: "0"}, &config{chroot: dir})
+	}
+
+	for i, cf := range good {
+		if err := checkPrivileges(cf); err != nil {
+			t.Fatalf("good %d: %v", i, err)
+		}
+	}
+	for i, cf := range bad {
+		if err := checkPrivileges(cf); err == nil {
+			t.Fatalf("bad %d: expected error", i)
+		}
+	}
+}
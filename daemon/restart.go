@@ -0,0 +1,156 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 14:05 (EDT)
+// Function: restart policy
+
+package daemon
+
+import (
+	"time"
+
+	"github.com/jaw0/acgo/diag"
+)
+
+type RestartPolicy struct {
+	// after a crash: start with Delay, multiply by Backoff each time, up to MaxDelay
+	Delay    time.Duration
+	MaxDelay time.Duration
+	Backoff  float64
+	// the first delay, if the child was killed by a signal. then backs off, as above
+	SignalDelay time.Duration
+	// after the child exits with ExitRestart
+	RestartDelay time.Duration
+	// a child that exits with ExitRestart sooner than this is treated as a crash.
+	// (log.Fatal also exits 1)
+	MinUptime time.Duration
+	// a child that runs at least this long resets the backoff
+	ResetAfter time.Duration
+	// give up after MaxRestarts crashes within Window. 0 = never give up
+	MaxRestarts int
+	Window      time.Duration
+	// called when giving up, before exiting
+	GiveUp func()
+}
+
+var Restart = &RestartPolicy{
+	Delay:        5 * time.Second,
+	MaxDelay:     5 * time.Minute,
+	Backoff:      2,
+	SignalDelay:  time.Second,
+	RestartDelay: 5 * time.Second,
+	MinUptime:    time.Minute,
+	ResetAfter:   10 * time.Minute,
+	MaxRestarts:  0,
+	Window:       10 * time.Minute,
+}
+
+type exitReason int
+
+const (
+	exitClean     exitReason = iota // exited 0
+	exitRestart                     // exited ExitRestart
+	exitCrash                       // exited non-zero
+	exitSignal                      // killed by a signal
+	exitRequested                   // we asked it to restart
+)
+
+var dl = diag.Logger("daemon")
+
+func (r exitReason) String() string {
+	switch r {
+	case exitClean:
+		return "finished"
+	case exitRestart:
+		return "restart requested"
+	case exitCrash:
+		return "crashed"
+	case exitSignal:
+		return "killed by signal"
+	case exitRequested:
+		return "restarted"
+	}
+	return "?"
+}
+
+func classifyExit(st *ExitStatus) exitReason {
+
+	if st.Signal != 0 {
+		return exitSignal
+	}
+
+	switch st.Code {
+	case ExitFinished:
+		return exitClean
+	case ExitRestart:
+		return exitRestart
+	case -1:
+		return exitSignal
+	}
+	return exitCrash
+}
+
+type restarter struct {
+	policy  *RestartPolicy
+	delay   time.Duration
+	backoff bool // crashed recently
+	crashes []time.Time
+}
+
+// how long to wait before restarting. false => give up
+func (r *restarter) next(why exitReason, uptime time.Duration, now time.Time) (time.Duration, bool) {
+
+	p := r.policy
+
+	if p.ResetAfter > 0 && uptime >= p.ResetAfter {
+		r.delay = 0
+		r.backoff = false
+	}
+
+	switch why {
+	case exitRequested, exitClean:
+		return 0, true
+	case exitRestart:
+		if uptime >= p.MinUptime {
+			// intentional, does not count against the limit
+			return p.RestartDelay, true
+		}
+		// probably a startup failure. treat it as a crash
+	}
+
+	// keep only the recent crashes
+	recent := r.crashes[:0]
+	for _, t := range r.crashes {
+		if p.Window == 0 || now.Sub(t) < p.Window {
+			recent = append(recent, t)
+		}
+	}
+	r.crashes = append(recent, now)
+
+	if p.MaxRestarts > 0 && len(r.crashes) > p.MaxRestarts {
+		return 0, false
+	}
+
+	switch {
+	case !r.backoff && why == exitSignal:
+		r.delay = p.SignalDelay
+	case r.delay == 0:
+		r.delay = p.Delay
+	case p.Backoff > 1:
+		r.delay = time.Duration(float64(r.delay) * p.Backoff)
+	}
+	if p.MaxDelay > 0 && r.delay > p.MaxDelay {
+		r.delay = p.MaxDelay
+	}
+	r.backoff = true
+
+	return r.delay, true
+}
+
+func (r *restarter) giveUp() {
+
+	dl.Problem("child crashed %d times in %s, giving up", len(r.crashes), r.policy.Window)
+
+	if r.policy.GiveUp != nil {
+		r.policy.GiveUp()
+	}
+}
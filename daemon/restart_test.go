@@ -0,0 +1,70 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 10:05 (EDT)
+// Function: test the restart policy
+
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicy(t *testing.T) {
+
+	policy := *Restart
+	policy.MaxRestarts = 3
+	now := time.Now()
+
+	// a child that asks to restart after running a while
+	r := &restarter{policy: &policy}
+	for i := 0; i < 10; i++ {
+		delay, ok := r.next(exitRestart, time.Hour, now)
+		if !ok || delay != policy.RestartDelay {
+			t.Fatalf("restart %d: got %v, %v", i, delay, ok)
+		}
+	}
+
+	// restarts we requested are immediate, and do not count
+	for i := 0; i < 10; i++ {
+		delay, ok := r.next(exitRequested, 0, now)
+		if !ok || delay != 0 {
+			t.Fatalf("requested %d: got %v, %v", i, delay, ok)
+		}
+	}
+
+	// exit 1 at startup (eg. log.Fatal) backs off, then gives up
+	r = &restarter{policy: &policy}
+	expect := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second}
+	for i, d := range expect {
+		delay, ok := r.next(exitRestart, time.Second, now)
+		if !ok || delay != d {
+			t.Fatalf("early restart %d: got %v, %v, expected %v", i, delay, ok, d)
+		}
+	}
+	if _, ok := r.next(exitRestart, time.Second, now); ok {
+		t.Fatalf("expected to give up")
+	}
+
+	// killed by signals: a quick first restart, then backs off
+	policy.MaxRestarts = 0
+	r = &restarter{policy: &policy}
+	expect = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, d := range expect {
+		delay, ok := r.next(exitSignal, time.Second, now)
+		if !ok || delay != d {
+			t.Fatalf("signal %d: got %v, %v, expected %v", i, delay, ok, d)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		r.next(exitSignal, time.Second, now)
+	}
+	if delay, _ := r.next(exitSignal, time.Second, now); delay != policy.MaxDelay {
+		t.Fatalf("expected max delay, got %v", delay)
+	}
+
+	// until it stays up a while
+	if delay, _ := r.next(exitSignal, time.Hour, now); delay != policy.SignalDelay {
+		t.Fatalf("expected reset, got %v", delay)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 12:05 (EDT)
+// Function: portability
+
+//go:build !freebsd && !dragonfly
+
+package daemon
+
+import "syscall"
+
+func mkRlimit(v uint64) *syscall.Rlimit {
+	return &syscall.Rlimit{Cur: v, Max: v}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 14:50 (EDT)
+// Function: graceful shutdown
+
+/*
+at startup:
+    daemon.OnShutdown("db", 10, 5*time.Second, func(){ db.Close() })
+    go daemon.SigExiter()
+
+hooks run in priority order, lowest first. hooks with the same
+priority run concurrently.
+*/
+
+package daemon
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// overall time limit for running the shutdown hooks, before forcing an exit
+var ShutdownGrace = 30 * time.Second
+
+// how long the supervisor waits for the child to exit after
+// passing along a terminating signal, before killing it
+var KillTimeout = 60 * time.Second
+
+type shutdownHook struct {
+	name    string
+	prio    int
+	timeout time.Duration
+	f       func()
+}
+
+var shutdownLock sync.Mutex
+var shutdownHooks []*shutdownHook
+var shutdownOnce sync.Once
+
+// OnShutdown registers a cleanup function to be run at shutdown
+func OnShutdown(name string, prio int, timeout time.Duration, f func()) {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+
+	shutdownHooks = append(shutdownHooks, &shutdownHook{name: name, prio: prio, timeout: timeout, f: f})
+}
+
+// Shutdown runs the shutdown hooks, and exits
+func Shutdown(code int) {
+
+	shutdownOnce.Do(func() {
+		done := make(chan struct{})
+		go func() {
+			runShutdownHooks()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(ShutdownGrace):
+			dl.Problem("shutdown did not finish in %s, exiting", ShutdownGrace)
+		}
+
+		os.Exit(code)
+	})
+
+	// someone else is already shutting down
+	select {}
+}
+
+func runShutdownHooks() {
+
+	shutdownLock.Lock()
+	hooks := make([]*shutdownHook, len(shutdownHooks))
+	copy(hooks, shutdownHooks)
+	shutdownLock.Unlock()
+
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].prio < hooks[j].prio })
+
+	for len(hooks) != 0 {
+		// run all hooks of the same priority together
+		n := 1
+		for n < len(hooks) && hooks[n].prio == hooks[0].prio {
+			n++
+		}
+
+		var wg sync.WaitGroup
+		for _, h := range hooks[:n] {
+			wg.Add(1)
+			go func(h *shutdownHook) {
+				defer wg.Done()
+				h.run()
+			}(h)
+		}
+		wg.Wait()
+		hooks = hooks[n:]
+	}
+}
+
+func (h *shutdownHook) run() {
+
+	dl.Debug("shutdown %s", h.name)
+
+	done := make(chan struct{})
+	go func() {
+		h.f()
+		close(done)
+	}()
+
+	if h.timeout == 0 {
+		<-done
+		return
+	}
+
+	select {
+	case <-done:
+	case <-time.After(h.timeout):
+		dl.Problem("shutdown %s did not finish in %s", h.name, h.timeout)
+	}
+}
+
+func isTermSignal(n os.Signal) bool {
+	switch n {
+	case syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-20 10:40 (EDT)
+// Function: test shutdown hooks
+
+package daemon
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownHooks(t *testing.T) {
+
+	type hook struct {
+		name    string
+		prio    int
+		timeout time.Duration
+		sleep   time.Duration
+	}
+
+	tests := []struct {
+		name    string
+		hooks   []hook
+		expect  []string      // order of completion
+		maxTime time.Duration // for all of them
+	}{
+		{
+			name:   "priority order",
+			hooks:  []hook{{name: "c", prio: 3}, {name: "a", prio: -1}, {name: "b", prio: 2}},
+			expect: []string{"a", "b", "c"},
+		},
+		{
+			name: "same priority runs together",
+			hooks: []hook{
+				{name: "a", prio: 1, sleep: 200 * time.Millisecond},
+				{name: "b", prio: 1, sleep: 200 * time.Millisecond},
+				{name: "c", prio: 2},
+			},
+			maxTime: 350 * time.Millisecond,
+		},
+		{
+			name: "timeout",
+			hooks: []hook{
+				{name: "slow", prio: 1, timeout: 50 * time.Millisecond, sleep: 5 * time.Second},
+				{name: "next", prio: 2},
+			},
+			expect:  []string{"next"},
+			maxTime: time.Second,
+		},
+	}
+
+	defer func() { shutdownHooks = nil }()
+
+	for _, tt := range tests {
+		var lock sync.Mutex
+		var done []string
+		shutdownHooks = nil
+
+		for _, h := range tt.hooks {
+			h := h
+			OnShutdown(h.name, h.prio, h.timeout, func() {
+				time.Sleep(h.sleep)
+				lock.Lock()
+				done = append(done, h.name)
+				lock.Unlock()
+			})
+		}
+
+		start := time.Now()
+		runShutdownHooks()
+		elapsed := time.Since(start)
+
+		lock.Lock()
+		got := append([]string{}, done...)
+		lock.Unlock()
+
+		if tt.expect != nil && !reflect.DeepEqual(got, tt.expect) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expect, got)
+		}
+		if tt.expect == nil && len(got) != len(tt.hooks) {
+			t.Errorf("%s: expected all hooks to run, got %v", tt.name, got)
+		}
+		if tt.maxTime != 0 && elapsed > tt.maxTime {
+			t.Errorf("%s: took %s", tt.name, elapsed)
+		}
+	}
+}
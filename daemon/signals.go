@@ -0,0 +1,123 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 14:15 (EDT)
+// Function: run actions on signals
+
+/*
+at startup, before daemon.Ize():
+    daemon.OnReload(func(){ readConfig() })
+    daemon.OnReopenLogs(func(){ accessLog.Reopen() })
+    daemon.OnSignal(syscall.SIGUSR2, daemon.DumpGoroutines)
+    daemon.Ize()
+    go daemon.SigExiter()
+
+a signal with registered actions runs them, instead of exiting.
+the supervisor passes the registered signals on to the children.
+*/
+
+package daemon
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+var sigLock sync.Mutex
+var sigActions = make(map[os.Signal][]func())
+
+// OnSignal arranges for f to be run by SigExiter when the signal arrives
+func OnSignal(n os.Signal, f func()) {
+	sigLock.Lock()
+	defer sigLock.Unlock()
+
+	sigActions[n] = append(sigActions[n], f)
+}
+
+// OnReload runs f on SIGHUP, instead of restarting
+func OnReload(f func()) {
+	OnSignal(syscall.SIGHUP, f)
+}
+
+// OnReopenLogs runs f on SIGUSR1. the supervisor also reopens its Logfile
+func OnReopenLogs(f func()) {
+	OnSignal(syscall.SIGUSR1, f)
+}
+
+// DumpGoroutines logs the stacks of all goroutines
+func DumpGoroutines() {
+
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	dl.Verbose("goroutines:\n%s", buf)
+}
+
+// the signals with registered actions
+func handledSignals() []os.Signal {
+	sigLock.Lock()
+	defer sigLock.Unlock()
+
+	var sigs []os.Signal
+	for n := range sigActions {
+		sigs = append(sigs, n)
+	}
+	return sigs
+}
+
+// does the program have actions registered for the signal
+func isHandled(n os.Signal) bool {
+	sigLock.Lock()
+	defer sigLock.Unlock()
+
+	return len(sigActions[n]) != 0
+}
+
+// run the registered actions, if any. returns false if there are none
+func runSignalActions(n os.Signal) bool {
+	sigLock.Lock()
+	actions := sigActions[n]
+	sigLock.Unlock()
+
+	if len(actions) == 0 {
+		return false
+	}
+
+	dl.Verbose("caught %v", n)
+	go func() {
+		for _, f := range actions {
+			f()
+		}
+	}()
+
+	return true
+}
+
+// add the signals not already in the list
+func addSignals(sigs []os.Signal, more []os.Signal) []os.Signal {
+
+	res := append([]os.Signal{}, sigs...)
+
+	for _, n := range more {
+		dup := false
+		for _, s := range res {
+			if s == n {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			res = append(res, n)
+		}
+	}
+
+	return res
+}
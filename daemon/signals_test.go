@@ -0,0 +1,47 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 14:50 (EDT)
+// Function: test signal actions
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalActions(t *testing.T) {
+
+	defer func() { sigActions = make(map[os.Signal][]func()) }()
+
+	if runSignalActions(syscall.SIGHUP) {
+		t.Fatalf("no actions registered, should not be handled")
+	}
+
+	done := make(chan struct{})
+	OnReload(func() { close(done) })
+
+	if !isHandled(syscall.SIGHUP) || isHandled(syscall.SIGUSR1) {
+		t.Fatalf("wrong handled signals")
+	}
+	if !runSignalActions(syscall.SIGHUP) {
+		t.Fatalf("should be handled")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("action did not run")
+	}
+
+	sigs := addSignals(defaultSignals, handledSignals())
+	if len(sigs) != len(defaultSignals) {
+		t.Fatalf("duplicate signals: %v", sigs)
+	}
+	sigs = addSignals(defaultSignals, []os.Signal{syscall.SIGUSR2})
+	if len(sigs) != len(defaultSignals)+1 || len(defaultSignals) != 4 {
+		t.Fatalf("wrong signals: %v", sigs)
+	}
+}
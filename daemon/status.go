@@ -0,0 +1,139 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 15:20 (EDT)
+// Function: machine readable status file
+
+/*
+the supervisor writes a json status file, updated whenever anything changes:
+    daemon.StatusFile = "/var/run/myprog.status"
+    daemon.Version = "1.2.3"
+    daemon.Ize()
+
+monitoring programs can read it with daemon.ReadStatus(file).
+the file is left in place after the supervisor exits, with state "stopped".
+*/
+
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// write the status here. "" = don't
+var StatusFile = ""
+
+// the program's version, for the status file
+var Version = ""
+
+// Status is the content of the status file
+type Status struct {
+	Program  string        `json:"program"`
+	Version  string        `json:"version,omitempty"`
+	Pid      int           `json:"pid"` // the supervisor
+	State    string        `json:"state"`
+	ExitCode int           `json:"exit_code"` // once stopped
+	Started  time.Time     `json:"started"`
+	Updated  time.Time     `json:"updated"`
+	Children []ChildStatus `json:"children"`
+	Stats    Stats         `json:"stats"`
+}
+
+// ChildStatus describes one child
+type ChildStatus struct {
+	Worker   int       `json:"worker"`
+	Pid      int       `json:"pid"` // 0 if not running
+	Started  time.Time `json:"started"`
+	Restarts int       `json:"restarts"`
+	LastExit string    `json:"last_exit,omitempty"`
+	Usage    *Usage    `json:"last_usage,omitempty"` // of the last run
+}
+
+// ReadStatus reads a status file
+func ReadStatus(file string) (*Status, error) {
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{}
+	err = json.Unmarshal(b, st)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (s *Supervisor) snapshot(state string) *Status {
+
+	st := &Status{
+		Program:  s.Prog,
+		Version:  Version,
+		Pid:      os.Getpid(),
+		State:    state,
+		ExitCode: s.code,
+		Started:  s.started,
+		Updated:  s.Now(),
+		Children: []ChildStatus{},
+		Stats:    s.Stats(),
+	}
+
+	for _, c := range s.children {
+		cs := ChildStatus{
+			Worker:   c.index,
+			Restarts: c.restarts,
+			LastExit: c.lastExit,
+			Usage:    c.lastUsage,
+		}
+		if c.proc != nil {
+			cs.Pid = c.proc.Pid()
+			cs.Started = c.started
+		}
+		st.Children = append(st.Children, cs)
+	}
+
+	return st
+}
+
+func (s *Supervisor) state() string {
+	if s.stopping {
+		return "stopping"
+	}
+	return "running"
+}
+
+// write the status file, atomically
+func (s *Supervisor) saveStatus(state string) {
+
+	if s.StatusFile == "" {
+		return
+	}
+
+	b, _ := json.MarshalIndent(s.snapshot(state), "", "  ")
+
+	f, err := ioutil.TempFile(filepath.Dir(s.StatusFile), ".status")
+	if err != nil {
+		dl.Verbose("cannot save status: %v", err)
+		return
+	}
+
+	_, err = f.Write(append(b, '\n'))
+	if err == nil {
+		err = f.Close()
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.StatusFile)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		dl.Verbose("cannot save status: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 15:55 (EDT)
+// Function: test the status file
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSupervisorStatus(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "test.status")
+	started := make(chan *fakeProc, 1)
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		if n == 1 {
+			p.exit <- &ExitStatus{Code: 3}
+			return
+		}
+		p.onSignal = map[os.Signal]*ExitStatus{syscall.SIGTERM: {Code: 0}}
+		started <- p
+	}}
+
+	s := testSupervisor(f)
+	s.StatusFile = file
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+
+	p := <-started
+
+	// wait for the status to catch up
+	var st *Status
+	for i := 0; i < 100; i++ {
+		st, _ = ReadStatus(file)
+		if st != nil && len(st.Children) == 1 && st.Children[0].Pid == p.Pid() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if st == nil || len(st.Children) != 1 {
+		t.Fatalf("wrong status: %+v", st)
+	}
+	if st.State != "running" || st.Pid != os.Getpid() || st.Program != "/bin/test" {
+		t.Fatalf("wrong status: %+v", st)
+	}
+	c := st.Children[0]
+	if c.Pid != p.Pid() || c.Restarts != 1 || c.LastExit == "" {
+		t.Fatalf("wrong child status: %+v", c)
+	}
+
+	s.Signals <- syscall.SIGTERM
+	<-done
+
+	st, err := ReadStatus(file)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if st.State != "stopped" || st.ExitCode != 0 || len(st.Children) != 0 {
+		t.Fatalf("wrong final status: %+v", st)
+	}
+}
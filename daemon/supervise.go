@@ -0,0 +1,707 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 17:20 (EDT)
+// Function: watch + restart the children
+
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Process is a running child
+type Process interface {
+	Pid() int
+	Signal(os.Signal) error
+	Wait() (*ExitStatus, error)
+}
+
+// ExitStatus describes how a child exited
+type ExitStatus struct {
+	Code   int            // exit code, -1 if killed by a signal
+	Signal syscall.Signal // the signal, if killed by one
+	Usage  *Usage         // resources used, if known
+}
+
+func (e *ExitStatus) String() string {
+	if e.Signal != 0 {
+		return "signal: " + e.Signal.String()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Supervisor starts the children, and restarts them as needed.
+// the zero values of the function fields use the real os + clock
+type Supervisor struct {
+	Prog        string
+	Args        []string
+	Env         []string // the children's environment (default: ours)
+	Dir         string   // the children's working directory (default: ours)
+	Pidfile     string
+	StatusFile  string    // write the json status here
+	Logfile     string    // save the children's output here (rotated), or
+	Output      io.Writer // write the children's output here. default: discard it
+	LogMaxSize  int64     // default: LogMaxSize
+	LogKeep     int       // default: LogKeep
+	MemoryWarn  int64     // complain if a child uses more (bytes). 0 = don't check
+	Restart     *RestartPolicy
+	KillTimeout time.Duration
+	// number of worker children. 0 = a single child, not a worker
+	Workers int
+	// restart the children when these files change
+	Watch         []string
+	WatchDebounce time.Duration
+
+	// signals to pass on to the children. default: SIGINT, SIGTERM, SIGQUIT, SIGHUP
+	// (+ SIGTTIN, SIGTTOU with workers). other programs: none, see Signal
+	Forward []os.Signal
+	// incoming signals
+	Signals chan os.Signal
+
+	Spawn func(prog string, args []string, attr *os.ProcAttr) (Process, error)
+	Now   func() time.Time
+	After func(time.Duration) <-chan time.Time
+
+	self    bool   // re-exec of ourself (vs. some other program)
+	ctlSock string // listen here, if set
+	name    string
+	done    chan struct{}
+	quit    chan struct{} // closed when Run finishes
+	result  int
+	ctlchan chan *ctlReq
+	events  chan *event
+	lfiles  []*os.File
+	lenv    string
+	logw    *logWriter
+	crashes crashReporter
+	// resource usage totals
+	statLock sync.Mutex
+	stats    Stats
+
+	started  time.Time
+	children []*child
+	stopping bool
+	code     int
+}
+
+// one child process, restarted as needed
+type child struct {
+	index      int
+	gen        int // incremented on each start
+	proc       Process
+	started    time.Time
+	restarts   int
+	lastExit   string
+	restartReq bool
+	retire     bool
+	killing    bool
+	rs         *restarter
+	mondone    chan struct{}
+	lastUsage  *Usage
+	memWarned  bool
+}
+
+const (
+	evExit = iota
+	evRestart
+	evKill
+	evHung
+	evChanged
+	evMemCheck
+)
+
+type event struct {
+	kind int
+	c    *child
+	gen  int
+	st   *ExitStatus
+	msg  string
+}
+
+// NewSupervisor returns a Supervisor configured from the package settings
+func NewSupervisor(prog string) *Supervisor {
+	return newSupervisor(prog, defaultConfig())
+}
+
+func newSupervisor(prog string, cf *config) *Supervisor {
+	s := &Supervisor{
+		Prog:        prog,
+		Args:        os.Args,
+		Pidfile:     cf.pidfile,
+		StatusFile:  cf.statusFile,
+		Logfile:     cf.logfile,
+		LogMaxSize:  cf.logMaxSize,
+		LogKeep:     cf.logKeep,
+		MemoryWarn:  cf.memoryWarn,
+		Restart:     cf.restart,
+		KillTimeout: cf.killTimeout,
+		Workers:     cf.workers,
+		Forward:     cf.forward,
+		self:        true,
+		ctlSock:     controlSocket(path.Base(prog), cf),
+	}
+
+	if len(cf.env) != 0 {
+		s.Env = mergeEnv(os.Environ(), cf.env)
+	}
+
+	if WatchExecutable {
+		s.Watch = append(s.Watch, prog)
+	}
+	s.Watch = append(s.Watch, WatchFiles...)
+	s.WatchDebounce = WatchDebounce
+
+	return s
+}
+
+func (s *Supervisor) init() error {
+
+	if s.Spawn == nil {
+		s.Spawn = startProcess
+	}
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	if s.After == nil {
+		s.After = time.After
+	}
+	if s.Env == nil {
+		s.Env = os.Environ()
+	}
+	if s.Restart == nil {
+		s.Restart = Restart
+	}
+	if s.LogMaxSize == 0 {
+		s.LogMaxSize = LogMaxSize
+	}
+	if s.LogKeep == 0 {
+		s.LogKeep = LogKeep
+	}
+	if s.Signals == nil {
+		s.Signals = make(chan os.Signal, 5)
+	}
+	if s.self {
+		sigs := s.Forward
+		if sigs == nil {
+			sigs = defaultSignals
+		}
+		sigs = addSignals(sigs, handledSignals())
+		signal.Notify(s.Signals, sigs...)
+		if s.Workers > 0 {
+			signal.Notify(s.Signals, syscall.SIGTTIN, syscall.SIGTTOU)
+		}
+	} else if len(s.Forward) != 0 {
+		signal.Notify(s.Signals, s.Forward...)
+	}
+
+	s.name = path.Base(s.Prog)
+	s.ctlchan = make(chan *ctlReq)
+	s.events = make(chan *event)
+	s.quit = make(chan struct{})
+	s.started = s.Now()
+
+	switch {
+	case s.Logfile != "":
+		s.logw = newLogWriter(s.Logfile, s.LogMaxSize, s.LogKeep, LogTail)
+	case s.Output != nil:
+		s.logw = newOutputWriter(s.Output, LogTail)
+	}
+
+	if s.WatchDebounce == 0 {
+		s.WatchDebounce = WatchDebounce
+	}
+	err := s.watchFiles()
+	if err != nil {
+		return fmt.Errorf("cannot watch files: %v", err)
+	}
+
+	if !s.self {
+		return nil
+	}
+
+	s.lfiles, s.lenv, err = openListeners()
+	if err != nil {
+		return fmt.Errorf("cannot listen: %v", err)
+	}
+
+	if s.ctlSock != "" {
+		err = s.listenControl(s.ctlSock)
+		if err != nil {
+			return fmt.Errorf("cannot open control socket: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Run runs the children until they finish, or we are told to stop.
+// returns the exit code for the supervisor
+func (s *Supervisor) Run() int {
+
+	if s.Pidfile != "" {
+		if err := SavePidFile(s.Pidfile); err != nil {
+			dl.Problem("cannot save pidfile: %v", err)
+			return 2
+		}
+	}
+
+	err := s.init()
+	if err != nil {
+		dl.Problem("cannot daemonize: %v", err)
+		return s.finish(2)
+	}
+
+	n := s.Workers
+	if n < 1 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		s.addChild()
+	}
+	if s.MemoryWarn > 0 {
+		s.later(MemoryCheckInterval, &event{kind: evMemCheck})
+	}
+	s.saveStatus(s.state())
+
+	for len(s.children) != 0 {
+		select {
+		case ev := <-s.events:
+			s.handle(ev)
+		case n := <-s.Signals:
+			s.signal(n)
+		case req := <-s.ctlchan:
+			s.control(req)
+		}
+		s.saveStatus(s.state())
+	}
+
+	return s.finish(s.code)
+}
+
+// clean up, before exiting
+func (s *Supervisor) finish(code int) int {
+
+	s.code = code
+	s.saveStatus("stopped")
+
+	// release any pending timers and watchers
+	signal.Stop(s.Signals)
+	close(s.quit)
+
+	if s.Pidfile != "" {
+		RemovePidFile(s.Pidfile)
+	}
+	if s.self && controlPath != "" {
+		os.Remove(controlPath)
+	}
+
+	return code
+}
+
+func (s *Supervisor) addChild() {
+
+	// use the lowest free index
+	idx := 0
+	for s.findChild(idx) != nil {
+		idx++
+	}
+
+	c := &child{index: idx, rs: &restarter{policy: s.Restart}}
+	s.children = append(s.children, c)
+	s.start(c)
+}
+
+func (s *Supervisor) findChild(idx int) *child {
+	for _, c := range s.children {
+		if c.index == idx {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) removeChild(c *child) {
+	for i, cc := range s.children {
+		if cc == c {
+			s.children = append(s.children[:i], s.children[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Supervisor) start(c *child) {
+
+	err := s.startChild(c)
+	if err == nil {
+		return
+	}
+
+	// same as a crash, it may be temporary
+	c.lastExit = fmt.Sprintf("cannot start: %v", err)
+	delay, ok := c.rs.next(exitCrash, 0, s.Now())
+	if !ok {
+		dl.Problem("cannot start %s: %v", s.Prog, err)
+		c.rs.giveUp()
+		s.code = 2
+		s.stop()
+		s.removeChild(c)
+		return
+	}
+
+	s.report(fmt.Sprintf("cannot start %s: %v, retrying in %s", s.Prog, err, delay))
+	s.later(delay, &event{kind: evRestart, c: c, gen: c.gen})
+}
+
+func (s *Supervisor) handle(ev *event) {
+
+	switch ev.kind {
+	case evChanged:
+		dl.Verbose("%s changed, restarting", ev.msg)
+		s.restartAll()
+		return
+	case evMemCheck:
+		s.checkMemory()
+		return
+	}
+
+	c := ev.c
+	if ev.gen != c.gen {
+		// stale
+		return
+	}
+
+	switch ev.kind {
+	case evExit:
+		s.exited(c, ev.st)
+	case evRestart:
+		if c.proc == nil && !s.stopping {
+			c.restarts++
+			s.start(c)
+		}
+	case evKill:
+		if c.proc != nil {
+			s.report(fmt.Sprintf("child %d did not exit in time, killing", c.proc.Pid()))
+			c.proc.Signal(syscall.SIGKILL)
+		}
+	case evHung:
+		if c.proc != nil {
+			// get a stack dump, then kill it
+			s.report(fmt.Sprintf("child %d is hung (%s), restarting", c.proc.Pid(), ev.msg))
+			c.proc.Signal(syscall.SIGABRT)
+			s.startKiller(c, hungKillTimeout)
+		}
+	}
+}
+
+func (s *Supervisor) exited(c *child, st *ExitStatus) {
+
+	pid := c.proc.Pid()
+	c.proc = nil
+	c.killing = false
+	close(c.mondone)
+
+	why := classifyExit(st)
+	if c.restartReq {
+		why = exitRequested
+		c.restartReq = false
+	}
+	c.lastExit = fmt.Sprintf("%s (%s)", why, st)
+	crashed := why == exitCrash || why == exitSignal
+	s.account(c, pid, st, crashed)
+
+	if why == exitClean || s.stopping || c.retire {
+		// done
+		s.removeChild(c)
+		return
+	}
+
+	delay, ok := c.rs.next(why, s.Now().Sub(c.started), s.Now())
+	if !ok {
+		if crashed {
+			s.reportCrash(c, pid, st, "giving up")
+		}
+		c.rs.giveUp()
+		s.code = 2
+		s.stop()
+		s.removeChild(c)
+		return
+	}
+
+	if crashed {
+		s.reportCrash(c, pid, st, fmt.Sprintf("restarting in %s", delay))
+	} else {
+		dl.Verbose("child %s, restarting in %s", c.lastExit, delay)
+	}
+
+	s.later(delay, &event{kind: evRestart, c: c, gen: c.gen})
+}
+
+// send ev after delay
+func (s *Supervisor) later(delay time.Duration, ev *event) {
+	timer := s.After(delay)
+	go func() {
+		select {
+		case <-timer:
+		case <-s.quit:
+			return
+		}
+		select {
+		case s.events <- ev:
+		case <-s.quit:
+		}
+	}()
+}
+
+// pass the signal on through to the running programs
+func (s *Supervisor) signal(n os.Signal) {
+
+	switch {
+	case n == syscall.SIGTTIN && s.Workers > 0:
+		s.scale(len(s.children) + 1)
+		return
+	case n == syscall.SIGTTOU && s.Workers > 0:
+		s.scale(len(s.children) - 1)
+		return
+	case isTermSignal(n):
+		s.stopping = true
+	case n == syscall.SIGUSR1 && s.logw != nil:
+		// ours too
+		if err := s.logw.Reopen(); err != nil {
+			dl.Problem("cannot reopen log: %v", err)
+		}
+	}
+
+	// without a reload action, the child exits ExitRestart (eg. for a new binary).
+	// start the new one right away, the listeners are not accepting meanwhile
+	restart := n == syscall.SIGHUP && s.self && !isHandled(n)
+
+	for _, c := range append([]*child{}, s.children...) {
+		if c.proc == nil {
+			if s.stopping {
+				// waiting to restart. never mind
+				s.removeChild(c)
+			}
+			continue
+		}
+
+		if c.retire {
+			// already on its way out
+			continue
+		}
+
+		c.proc.Signal(n)
+		if s.stopping {
+			s.startKiller(c, s.KillTimeout)
+		} else if restart {
+			c.restartReq = true
+		}
+	}
+}
+
+// tell all the children to stop
+func (s *Supervisor) stop() {
+	s.signal(syscall.SIGTERM)
+}
+
+// restart all the children
+func (s *Supervisor) restartAll() {
+
+	for _, c := range append([]*child{}, s.children...) {
+		if c.proc == nil {
+			// waiting to restart. do it now
+			c.gen++
+			c.restarts++
+			s.start(c)
+			continue
+		}
+
+		c.restartReq = true
+		c.proc.Signal(syscall.SIGTERM)
+		s.startKiller(c, s.KillTimeout)
+	}
+}
+
+// give it a chance to shut down cleanly
+func (s *Supervisor) startKiller(c *child, timeout time.Duration) {
+
+	if c.killing {
+		return
+	}
+
+	c.killing = true
+	s.later(timeout, &event{kind: evKill, c: c, gen: c.gen})
+}
+
+func (s *Supervisor) startChild(c *child) error {
+
+	dn, err := os.OpenFile(os.DevNull, os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer dn.Close()
+
+	out := dn
+	var outdone chan struct{}
+
+	if s.logw != nil {
+		if len(s.children) == 1 {
+			s.logw.ResetTail()
+		}
+		out, outdone, err = s.logw.pipe()
+		if err != nil {
+			s.report(fmt.Sprintf("cannot capture output: %v", err))
+			out = dn
+		} else {
+			defer out.Close()
+		}
+	}
+
+	files := []*os.File{dn, out, out}
+	env := s.childEnv()
+	var hbr *os.File
+
+	if s.self {
+		files = append(files, s.lfiles...)
+		env = append(env, ENVVAR+"=2", LISTENVAR+"="+s.lenv)
+
+		if s.Workers > 0 {
+			env = append(env, WORKERVAR+"="+strconv.Itoa(c.index))
+		}
+
+		var hbw *os.File
+		hbw, hbr, err = heartbeatPipe()
+		if err != nil {
+			return err
+		}
+		if hbw != nil {
+			env = append(env, HEARTBEATVAR+"="+strconv.Itoa(len(files)))
+			files = append(files, hbw)
+			defer hbw.Close()
+		}
+	}
+
+	pa := &os.ProcAttr{Files: files, Env: env, Dir: s.Dir}
+	p, err := s.Spawn(s.Prog, s.Args, pa)
+	if err != nil {
+		if hbr != nil {
+			hbr.Close()
+		}
+		return err
+	}
+
+	c.gen++
+	c.proc = p
+	c.started = s.Now()
+	s.monitor(c, hbr)
+
+	ev := &event{kind: evExit, c: c, gen: c.gen}
+	go func() {
+		st, err := p.Wait()
+		if err != nil {
+			st = &ExitStatus{Code: -1}
+		}
+		if outdone != nil {
+			// grandchildren may still have it open
+			select {
+			case <-outdone:
+			case <-time.After(time.Second):
+			}
+		}
+		ev.st = st
+		s.events <- ev
+	}()
+
+	return nil
+}
+
+// our env, minus our private vars
+func (s *Supervisor) childEnv() []string {
+
+	var env []string
+
+	for _, e := range s.Env {
+		switch {
+		case hasEnvName(e, ENVVAR), hasEnvName(e, LISTENVAR), hasEnvName(e, HEARTBEATVAR), hasEnvName(e, WORKERVAR):
+			continue
+		}
+		env = append(env, e)
+	}
+
+	return env
+}
+
+func hasEnvName(e string, name string) bool {
+	return len(e) > len(name) && e[:len(name)] == name && e[len(name)] == '='
+}
+
+// watch for a hung child
+func (s *Supervisor) monitor(c *child, hbr *os.File) {
+
+	done := make(chan struct{})
+	hung := make(chan string)
+	c.mondone = done
+
+	if hbr != nil {
+		grace, timeout := HealthGrace, heartbeatTimeout()
+		go func() {
+			monitorHeartbeat(hbr, grace, timeout, hung, done)
+			hbr.Close()
+		}()
+	}
+
+	if HealthProbe != "" && s.self && s.Workers == 0 {
+		// the probe checks the service, not a particular worker
+		go monitorProbe(HealthProbe, HealthGrace, HealthProbeInterval, HealthProbeFailures, hung, done)
+	}
+
+	ev := &event{kind: evHung, c: c, gen: c.gen}
+	go func() {
+		select {
+		case ev.msg = <-hung:
+			s.events <- ev
+		case <-done:
+		}
+	}()
+}
+
+type osProcess struct {
+	p *os.Process
+}
+
+func startProcess(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+
+	p, err := os.StartProcess(prog, args, attr)
+	if err != nil {
+		return nil, err
+	}
+	return &osProcess{p}, nil
+}
+
+func (p *osProcess) Pid() int                 { return p.p.Pid }
+func (p *osProcess) Signal(n os.Signal) error { return p.p.Signal(n) }
+
+func (p *osProcess) Wait() (*ExitStatus, error) {
+
+	st, err := p.p.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	es := &ExitStatus{Code: st.ExitCode()}
+	if ws, ok := st.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		es.Signal = ws.Signal()
+	}
+	if ru, ok := st.SysUsage().(*syscall.Rusage); ok && ru != nil {
+		es.Usage = rusageToUsage(ru)
+	}
+
+	return es, nil
+}
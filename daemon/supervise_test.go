@@ -0,0 +1,382 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 21:30 (EDT)
+// Function: test the supervisor
+
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type fakeProc struct {
+	pid      int
+	lock     sync.Mutex
+	signals  []os.Signal
+	exit     chan *ExitStatus
+	onSignal map[os.Signal]*ExitStatus
+}
+
+func (p *fakeProc) Pid() int { return p.pid }
+
+func (p *fakeProc) Signal(n os.Signal) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.signals = append(p.signals, n)
+	if st := p.onSignal[n]; st != nil {
+		p.exit <- st
+	}
+	return nil
+}
+
+func (p *fakeProc) Wait() (*ExitStatus, error) {
+	return <-p.exit, nil
+}
+
+func (p *fakeProc) Signals() []os.Signal {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]os.Signal{}, p.signals...)
+}
+
+type fakeSpawner struct {
+	lock   sync.Mutex
+	procs  []*fakeProc
+	setup  func(n int, p *fakeProc)
+	delays []time.Duration
+}
+
+func (f *fakeSpawner) spawn(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	p := &fakeProc{pid: 1000 + len(f.procs), exit: make(chan *ExitStatus, 5)}
+	f.procs = append(f.procs, p)
+	f.setup(len(f.procs), p)
+	return p, nil
+}
+
+// restart delays fire immediately. long timeouts never do
+func (f *fakeSpawner) after(d time.Duration) <-chan time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d < time.Hour {
+		f.delays = append(f.delays, d)
+		c <- time.Now()
+	}
+	return c
+}
+
+func testSupervisor(f *fakeSpawner) *Supervisor {
+	return &Supervisor{
+		Prog:        "/bin/test",
+		Args:        []string{"test"},
+		Env:         []string{"FOO=bar", ENVVAR + "=1"},
+		Restart:     &RestartPolicy{Delay: 5 * time.Second, MaxDelay: time.Minute, Backoff: 2, Window: time.Minute},
+		KillTimeout: 2 * time.Hour,
+		Signals:     make(chan os.Signal, 5),
+		Spawn:       f.spawn,
+		After:       f.after,
+		self:        true,
+	}
+}
+
+func runSupervisor(t *testing.T, s *Supervisor) int {
+
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+
+	select {
+	case code := <-done:
+		return code
+	case <-time.After(10 * time.Second):
+		t.Fatalf("supervisor did not finish")
+	}
+	return -1
+}
+
+func TestSupervisorCleanExit(t *testing.T) {
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		p.exit <- &ExitStatus{Code: ExitFinished}
+	}}
+
+	if code := runSupervisor(t, testSupervisor(f)); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	if len(f.procs) != 1 {
+		t.Fatalf("expected 1 start, got %d", len(f.procs))
+	}
+}
+
+func TestSupervisorRestart(t *testing.T) {
+
+	var env []string
+
+	f := &fakeSpawner{}
+	f.setup = func(n int, p *fakeProc) {
+		switch n {
+		case 1, 2:
+			p.exit <- &ExitStatus{Code: 3}
+		case 3:
+			p.exit <- &ExitStatus{Code: -1, Signal: syscall.SIGSEGV}
+		case 4:
+			p.exit <- &ExitStatus{Code: ExitRestart}
+		default:
+			p.exit <- &ExitStatus{Code: ExitFinished}
+		}
+	}
+
+	s := testSupervisor(f)
+	s.Restart.SignalDelay = time.Second
+	spawn := s.Spawn
+	s.Spawn = func(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+		env = attr.Env
+		return spawn(prog, args, attr)
+	}
+
+	if code := runSupervisor(t, s); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	if len(f.procs) != 5 {
+		t.Fatalf("expected 5 starts, got %d", len(f.procs))
+	}
+
+	expect := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 0}
+	if len(f.delays) != len(expect) {
+		t.Fatalf("expected delays %v, got %v", expect, f.delays)
+	}
+	for i := range expect {
+		if f.delays[i] != expect[i] {
+			t.Fatalf("expected delays %v, got %v", expect, f.delays)
+		}
+	}
+
+	envs := strings.Join(env, " ")
+	if !strings.Contains(envs, "FOO=bar") || !strings.Contains(envs, ENVVAR+"=2") || strings.Contains(envs, ENVVAR+"=1") {
+		t.Fatalf("bad child env: %v", env)
+	}
+}
+
+func TestSupervisorGiveUp(t *testing.T) {
+
+	gaveUp := false
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		p.exit <- &ExitStatus{Code: 3}
+	}}
+
+	s := testSupervisor(f)
+	s.Restart.MaxRestarts = 2
+	s.Restart.GiveUp = func() { gaveUp = true }
+
+	if code := runSupervisor(t, s); code != 2 {
+		t.Fatalf("expected 2, got %d", code)
+	}
+	if len(f.procs) != 3 || !gaveUp {
+		t.Fatalf("expected to give up after 3 starts, got %d", len(f.procs))
+	}
+}
+
+func TestSupervisorSpawnFails(t *testing.T) {
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		p.exit <- &ExitStatus{Code: ExitFinished}
+	}}
+
+	s := testSupervisor(f)
+	fails := 0
+	s.Spawn = func(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+		if fails < 2 {
+			fails++
+			return nil, syscall.EAGAIN
+		}
+		return f.spawn(prog, args, attr)
+	}
+
+	// retried per the policy
+	if code := runSupervisor(t, s); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+	if len(f.procs) != 1 {
+		t.Fatalf("expected 1 start, got %d", len(f.procs))
+	}
+	expect := []time.Duration{5 * time.Second, 10 * time.Second}
+	if len(f.delays) != len(expect) || f.delays[0] != expect[0] || f.delays[1] != expect[1] {
+		t.Fatalf("expected delays %v, got %v", expect, f.delays)
+	}
+
+	// and eventually given up on
+	s = testSupervisor(f)
+	s.Restart.MaxRestarts = 2
+	s.Spawn = func(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+		fails++
+		return nil, syscall.ENOENT
+	}
+	fails = 0
+
+	if code := runSupervisor(t, s); code != 2 {
+		t.Fatalf("expected 2, got %d", code)
+	}
+	if fails != 3 {
+		t.Fatalf("expected 3 attempts, got %d", fails)
+	}
+}
+
+func TestSupervisorReportLimit(t *testing.T) {
+
+	defer func(n int) { CrashReportLimit = n }(CrashReportLimit)
+	CrashReportLimit = 2
+
+	f := &fakeSpawner{}
+	s := testSupervisor(f)
+	s.Restart.MaxRestarts = 10
+	s.Spawn = func(prog string, args []string, attr *os.ProcAttr) (Process, error) {
+		return nil, syscall.ENOENT
+	}
+
+	if code := runSupervisor(t, s); code != 2 {
+		t.Fatalf("expected 2, got %d", code)
+	}
+
+	// the retries are reported, within the limit
+	if len(s.crashes.sent) != 2 || s.crashes.suppressed != 8 {
+		t.Fatalf("expected 2 reports, 8 suppressed; got %d, %d", len(s.crashes.sent), s.crashes.suppressed)
+	}
+}
+
+func TestSupervisorSignals(t *testing.T) {
+
+	started := make(chan *fakeProc, 1)
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		// stop, but not cleanly
+		p.onSignal = map[os.Signal]*ExitStatus{syscall.SIGTERM: {Code: 3}}
+		started <- p
+	}}
+
+	s := testSupervisor(f)
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+
+	p := <-started
+	s.Signals <- syscall.SIGHUP
+	s.Signals <- syscall.SIGTERM
+
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Fatalf("expected 0, got %d", code)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("supervisor did not stop")
+	}
+
+	sigs := p.Signals()
+	if len(sigs) != 2 || sigs[0] != syscall.SIGHUP || sigs[1] != syscall.SIGTERM {
+		t.Fatalf("expected HUP, TERM, got %v", sigs)
+	}
+	if len(f.procs) != 1 {
+		t.Fatalf("expected no restart, got %d starts", len(f.procs))
+	}
+}
+
+func TestSupervisorReexec(t *testing.T) {
+
+	started := make(chan *fakeProc, 2)
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		// a new binary, quickly
+		p.onSignal = map[os.Signal]*ExitStatus{
+			syscall.SIGHUP:  {Code: ExitRestart},
+			syscall.SIGTERM: {Code: 0},
+		}
+		started <- p
+	}}
+
+	s := testSupervisor(f)
+	s.Restart.RestartDelay = 5 * time.Second
+	s.Restart.MinUptime = time.Minute
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+
+	<-started
+	s.Signals <- syscall.SIGHUP
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("not restarted")
+	}
+	s.Signals <- syscall.SIGTERM
+	<-done
+
+	// immediately. not RestartDelay, nor as a crash
+	if len(f.delays) != 1 || f.delays[0] != 0 {
+		t.Fatalf("expected an immediate restart, got delays %v", f.delays)
+	}
+	if st := s.Stats(); st.Crashes != 0 {
+		t.Fatalf("counted as a crash")
+	}
+}
+
+func TestSupervisorKill(t *testing.T) {
+
+	started := make(chan *fakeProc, 1)
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		// ignore TERM
+		p.onSignal = map[os.Signal]*ExitStatus{syscall.SIGKILL: {Code: -1, Signal: syscall.SIGKILL}}
+		started <- p
+	}}
+
+	s := testSupervisor(f)
+	s.KillTimeout = time.Minute
+	done := make(chan int)
+	go func() { done <- s.Run() }()
+
+	p := <-started
+	s.Signals <- syscall.SIGTERM
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("supervisor did not stop")
+	}
+
+	sigs := p.Signals()
+	if len(sigs) != 2 || sigs[1] != syscall.SIGKILL {
+		t.Fatalf("expected TERM, KILL, got %v", sigs)
+	}
+}
+
+func TestSupervisorPidfile(t *testing.T) {
+
+	pidfile := filepath.Join(t.TempDir(), "test.pid")
+	var content []byte
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		content, _ = ioutil.ReadFile(pidfile)
+		p.exit <- &ExitStatus{Code: ExitFinished}
+	}}
+
+	s := testSupervisor(f)
+	s.Pidfile = pidfile
+
+	if code := runSupervisor(t, s); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+
+	if !strings.HasPrefix(string(content), strconv.Itoa(os.Getpid())+"\n") {
+		t.Fatalf("bad pidfile content %q", content)
+	}
+	if _, err := os.Stat(pidfile); !os.IsNotExist(err) {
+		t.Fatalf("pidfile not removed")
+	}
+}
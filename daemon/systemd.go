@@ -0,0 +1,144 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 19:40 (EDT)
+// Function: systemd integration
+
+/*
+when started by systemd as a Type=notify unit, Ize does not fork into the
+background, and does not supervise - systemd does that. (same with Foreground)
+Type=forking units work as before.
+
+for Type=simple or Type=exec units, set daemon.SystemdSimple = true
+(systemd does not tell us the unit type, so we cannot tell on our own).
+
+for Type=notify units, call daemon.NotifyReady() once ready to serve.
+if the unit has WatchdogSec=, set daemon.HealthCheck before calling Ize,
+the watchdog is pinged as long as it returns true.
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// if set, consulted before each watchdog ping
+var HealthCheck func() bool
+
+// run in the foreground whenever started by systemd, not just for Type=notify units
+var SystemdSimple = false
+
+// UnderSystemd returns true if we were started by systemd, and should not fork
+func UnderSystemd() bool {
+	if os.Getenv("NOTIFY_SOCKET") != "" {
+		return true
+	}
+	return SystemdSimple && os.Getenv("INVOCATION_ID") != ""
+}
+
+// Notify sends a state update to systemd (sd_notify).
+// does nothing if not running under systemd with notification enabled
+func Notify(state string) error {
+
+	sock := os.Getenv("NOTIFY_SOCKET")
+	if sock == "" {
+		return nil
+	}
+
+	if sock[0] == '@' {
+		// abstract namespace
+		sock = "\x00" + sock[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+func NotifyReady() error {
+	return Notify("READY=1")
+}
+
+func NotifyStatus(status string) error {
+	return Notify("STATUS=" + status)
+}
+
+func NotifyStopping() error {
+	return Notify("STOPPING=1")
+}
+
+func NotifyWatchdog() error {
+	return Notify("WATCHDOG=1")
+}
+
+// watchdogInterval returns the watchdog interval requested by systemd, or 0
+func watchdogInterval() time.Duration {
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// not for us
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Watchdog pings the systemd watchdog, at half the requested interval,
+// for as long as health returns true (or is nil). does nothing if no watchdog is requested
+func Watchdog(health func() bool) {
+
+	ival := watchdogInterval()
+	if ival == 0 {
+		return
+	}
+
+	go func() {
+		for {
+			if health == nil || health() {
+				NotifyWatchdog()
+			} else {
+				dl.Verbose("health check failed, not pinging watchdog")
+			}
+			time.Sleep(ival / 2)
+		}
+	}()
+}
+
+// run in the foreground, under systemd, or if requested
+func runForeground(cf *config) error {
+
+	if file := cf.pidfile; file != "" {
+		err := claimPidFile(file, cf.takeover)
+		if err == nil {
+			err = SavePidFile(file)
+		}
+		if err != nil {
+			return err
+		}
+		OnShutdown("pidfile", 1<<30, 0, func() { RemovePidFile(file) })
+	}
+
+	if UnderSystemd() {
+		OnShutdown("systemd", -1<<30, 0, func() { NotifyStopping() })
+	}
+
+	if err := dropPrivileges(cf); err != nil {
+		return fmt.Errorf("cannot drop privileges: %v", err)
+	}
+
+	Watchdog(HealthCheck)
+	return nil
+}
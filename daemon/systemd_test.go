@@ -0,0 +1,80 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-18 20:10 (EDT)
+// Function: test sd_notify
+
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+
+	sock := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", sock)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+	if !UnderSystemd() {
+		t.Fatalf("expected to be under systemd")
+	}
+
+	recv := func() string {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(buf[:n])
+	}
+
+	NotifyReady()
+	if m := recv(); m != "READY=1" {
+		t.Fatalf("expected READY=1, got %q", m)
+	}
+
+	NotifyStatus("doing fine")
+	if m := recv(); m != "STATUS=doing fine" {
+		t.Fatalf("expected STATUS, got %q", m)
+	}
+
+	if ival := watchdogInterval(); ival != 100*time.Millisecond {
+		t.Fatalf("expected 100ms watchdog, got %s", ival)
+	}
+
+	Watchdog(func() bool { return true })
+	if m := recv(); m != "WATCHDOG=1" {
+		t.Fatalf("expected WATCHDOG=1, got %q", m)
+	}
+}
+
+func TestUnderSystemd(t *testing.T) {
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	t.Setenv("INVOCATION_ID", "1234")
+	defer func(s bool) { SystemdSimple = s }(SystemdSimple)
+
+	// Type=forking units still fork
+	SystemdSimple = false
+	if UnderSystemd() {
+		t.Fatalf("INVOCATION_ID alone should not prevent forking")
+	}
+
+	SystemdSimple = true
+	if !UnderSystemd() {
+		t.Fatalf("expected to be under systemd")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 17:10 (EDT)
+// Function: resource accounting for the children
+
+/*
+the supervisor records the resource usage of each child when it exits,
+and keeps totals. see Supervisor.Stats, the status file, and control status.
+
+to spot leaks before the oom killer does:
+    daemon.MemoryWarn = 2 << 30
+    daemon.MemoryCheckInterval = time.Minute
+*/
+
+package daemon
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// complain if a child's resident memory exceeds this many bytes. 0 = don't check
+var MemoryWarn int64 = 0
+
+// how often to check
+var MemoryCheckInterval = 30 * time.Second
+
+// Usage is the resources used by one run of a child
+type Usage struct {
+	User       time.Duration `json:"user_cpu_ns"`
+	System     time.Duration `json:"system_cpu_ns"`
+	MaxRSS     int64         `json:"max_rss"` // bytes
+	VolCtxSw   int64         `json:"voluntary_ctx_switches"`
+	InvolCtxSw int64         `json:"involuntary_ctx_switches"`
+}
+
+// Stats is the totals over all runs of all children
+type Stats struct {
+	Runs       int           `json:"runs"`
+	Crashes    int           `json:"crashes"`
+	User       time.Duration `json:"user_cpu_ns"`
+	System     time.Duration `json:"system_cpu_ns"`
+	MaxRSS     int64         `json:"max_rss"` // largest seen
+	VolCtxSw   int64         `json:"voluntary_ctx_switches"`
+	InvolCtxSw int64         `json:"involuntary_ctx_switches"`
+}
+
+func (u *Usage) String() string {
+	return fmt.Sprintf("cpu %s user, %s sys; max rss %s",
+		u.User.Round(time.Millisecond), u.System.Round(time.Millisecond), fmtBytes(u.MaxRSS))
+}
+
+func rusageToUsage(ru *syscall.Rusage) *Usage {
+
+	u := &Usage{
+		User:       time.Duration(ru.Utime.Nano()),
+		System:     time.Duration(ru.Stime.Nano()),
+		MaxRSS:     int64(ru.Maxrss),
+		VolCtxSw:   int64(ru.Nvcsw),
+		InvolCtxSw: int64(ru.Nivcsw),
+	}
+
+	if runtime.GOOS != "darwin" {
+		// everyone else uses kB
+		u.MaxRSS *= 1024
+	}
+
+	return u
+}
+
+// Stats returns the resource usage totals
+func (s *Supervisor) Stats() Stats {
+	s.statLock.Lock()
+	defer s.statLock.Unlock()
+	return s.stats
+}
+
+// add a finished run to the totals
+func (s *Supervisor) account(c *child, pid int, st *ExitStatus, crashed bool) {
+
+	s.statLock.Lock()
+	defer s.statLock.Unlock()
+
+	s.stats.Runs++
+	if crashed {
+		s.stats.Crashes++
+	}
+
+	u := st.Usage
+	c.lastUsage = u
+	if u == nil {
+		return
+	}
+
+	s.stats.User += u.User
+	s.stats.System += u.System
+	s.stats.VolCtxSw += u.VolCtxSw
+	s.stats.InvolCtxSw += u.InvolCtxSw
+	if u.MaxRSS > s.stats.MaxRSS {
+		s.stats.MaxRSS = u.MaxRSS
+	}
+
+	if s.MemoryWarn > 0 && u.MaxRSS > s.MemoryWarn && !c.memWarned {
+		dl.Problem("child %d used %s of memory, limit %s", pid, fmtBytes(u.MaxRSS), fmtBytes(s.MemoryWarn))
+	}
+	c.memWarned = false
+}
+
+// check the running children's memory
+func (s *Supervisor) checkMemory() {
+
+	for _, c := range s.children {
+		if c.proc == nil {
+			continue
+		}
+
+		rss, ok := processRSS(c.proc.Pid())
+		if !ok {
+			continue
+		}
+
+		switch {
+		case rss > s.MemoryWarn && !c.memWarned:
+			dl.Problem("child %d is using %s of memory, limit %s", c.proc.Pid(), fmtBytes(rss), fmtBytes(s.MemoryWarn))
+			c.memWarned = true
+		case rss < s.MemoryWarn*9/10:
+			// warn again, if it grows again
+			c.memWarned = false
+		}
+	}
+
+	s.later(MemoryCheckInterval, &event{kind: evMemCheck})
+}
+
+func fmtBytes(n int64) string {
+
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fkB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 17:30 (EDT)
+// Function: memory usage of a running process
+
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// resident memory of pid, in bytes
+func processRSS(pid int) (int64, bool) {
+
+	b, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, false
+	}
+
+	// size resident shared ...; in pages
+	f := strings.Fields(string(b))
+	if len(f) < 2 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(f[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n * int64(os.Getpagesize()), true
+}
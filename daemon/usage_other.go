@@ -0,0 +1,13 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 17:30 (EDT)
+// Function: memory usage of a running process
+
+//go:build !linux
+
+package daemon
+
+// not available. the supervisor still checks max rss when the child exits
+func processRSS(pid int) (int64, bool) {
+	return 0, false
+}
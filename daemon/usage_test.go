@@ -0,0 +1,73 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 17:50 (EDT)
+// Function: test resource accounting
+
+package daemon
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSupervisorStats(t *testing.T) {
+
+	f := &fakeSpawner{setup: func(n int, p *fakeProc) {
+		code := 3
+		if n == 3 {
+			code = ExitFinished
+		}
+		p.exit <- &ExitStatus{Code: code, Usage: &Usage{
+			User:     time.Duration(n) * time.Second,
+			System:   time.Second,
+			MaxRSS:   int64(n) << 20,
+			VolCtxSw: 10,
+		}}
+	}}
+
+	s := testSupervisor(f)
+	if code := runSupervisor(t, s); code != 0 {
+		t.Fatalf("expected 0, got %d", code)
+	}
+
+	st := s.Stats()
+	if st.Runs != 3 || st.Crashes != 2 {
+		t.Fatalf("wrong runs: %+v", st)
+	}
+	if st.User != 6*time.Second || st.System != 3*time.Second || st.VolCtxSw != 30 {
+		t.Fatalf("wrong totals: %+v", st)
+	}
+	if st.MaxRSS != 3<<20 {
+		t.Fatalf("wrong max rss: %d", st.MaxRSS)
+	}
+}
+
+func TestProcessUsage(t *testing.T) {
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// run something real
+	p, err := startProcess(exe, []string{exe, "-test.run=^$"}, &os.ProcAttr{})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	st, err := p.Wait()
+	if err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	if st.Usage == nil || st.Usage.MaxRSS <= 0 {
+		t.Fatalf("no usage: %+v", st)
+	}
+
+	if runtime.GOOS == "linux" {
+		if rss, ok := processRSS(os.Getpid()); !ok || rss <= 0 {
+			t.Fatalf("no rss for ourself")
+		}
+	}
+}
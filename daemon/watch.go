@@ -0,0 +1,84 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 11:00 (EDT)
+// Function: restart when files change
+
+/*
+if enabled, the supervisor watches the executable and/or config files,
+and gracefully restarts the children when they change (eg. after a deploy).
+changes are debounced, so a deploy of several files causes one restart.
+*/
+
+package daemon
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// restart when the executable changes
+var WatchExecutable = false
+
+// restart when any of these change
+var WatchFiles []string
+
+// wait until things have been quiet this long
+var WatchDebounce = 2 * time.Second
+
+// start watching. changes are sent to the supervisor's main loop
+func (s *Supervisor) watchFiles() error {
+
+	if len(s.Watch) == 0 {
+		return nil
+	}
+
+	var files []string
+	for _, f := range s.Watch {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		files = append(files, abs)
+	}
+
+	changes, err := watchFiles(files, s.WatchDebounce, s.quit)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			var name string
+			select {
+			case name = <-changes:
+			case <-s.quit:
+				return
+			}
+			debounce := time.NewTimer(s.WatchDebounce)
+
+		quiet:
+			for {
+				select {
+				case <-changes:
+					if !debounce.Stop() {
+						<-debounce.C
+					}
+					debounce.Reset(s.WatchDebounce)
+				case <-debounce.C:
+					break quiet
+				case <-s.quit:
+					debounce.Stop()
+					return
+				}
+			}
+
+			select {
+			case s.events <- &event{kind: evChanged, msg: name}:
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
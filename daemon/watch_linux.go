@@ -0,0 +1,92 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 11:20 (EDT)
+// Function: watch files with inotify
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// files are typically replaced by renaming a new one into place,
+// so we watch the directories, and filter by name
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_ATTRIB
+
+func watchFiles(files []string, _ time.Duration, quit chan struct{}) (chan string, error) {
+
+	// non-blocking, so the runtime poller can interrupt the read when we close it
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	// wd => dir => names
+	dirs := make(map[int]map[string]bool)
+	wds := make(map[string]int)
+
+	for _, f := range files {
+		dir, name := filepath.Split(f)
+
+		wd, ok := wds[dir]
+		if !ok {
+			wd, err = syscall.InotifyAddWatch(fd, dir, inotifyMask)
+			if err != nil {
+				syscall.Close(fd)
+				return nil, &os.PathError{Op: "inotify", Path: dir, Err: err}
+			}
+			wds[dir] = wd
+			dirs[wd] = make(map[string]bool)
+		}
+		dirs[wd][name] = true
+	}
+
+	changes := make(chan string, 10)
+	f := os.NewFile(uintptr(fd), "inotify")
+
+	go func() {
+		<-quit
+		f.Close()
+	}()
+
+	go func() {
+		buf := make([]byte, 64*1024)
+
+		for {
+			n, err := f.Read(buf)
+			if err != nil || n <= 0 {
+				select {
+				case <-quit:
+				default:
+					dl.Problem("inotify read failed: %v", err)
+				}
+				return
+			}
+
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				nameb := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+				off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+				name := string(nameb)
+				for len(name) > 0 && name[len(name)-1] == 0 {
+					name = name[:len(name)-1]
+				}
+
+				if dirs[int(ev.Wd)][name] {
+					dl.Debug("changed: %s", name)
+					select {
+					case changes <- name:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 11:45 (EDT)
+// Function: watch files by polling
+
+//go:build !linux
+
+package daemon
+
+import (
+	"os"
+	"time"
+)
+
+type fileStamp struct {
+	mtime time.Time
+	size  int64
+}
+
+func stampFile(f string) fileStamp {
+	st, err := os.Stat(f)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mtime: st.ModTime(), size: st.Size()}
+}
+
+func watchFiles(files []string, ival time.Duration, quit chan struct{}) (chan string, error) {
+
+	stamps := make(map[string]fileStamp)
+	for _, f := range files {
+		stamps[f] = stampFile(f)
+	}
+
+	changes := make(chan string, 10)
+
+	go func() {
+		for {
+			select {
+			case <-time.After(ival):
+			case <-quit:
+				return
+			}
+
+			for _, f := range files {
+				st := stampFile(f)
+				if st != stamps[f] {
+					stamps[f] = st
+					if st == (fileStamp{}) {
+						// removed. wait for the new one
+						continue
+					}
+					select {
+					case changes <- f:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	return changes, nil
+}
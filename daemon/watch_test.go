@@ -0,0 +1,63 @@
+// Copyright (c) 2026
+// Author: Jeff Weisberg <jaw @ tcp4me.com>
+// Created: 2026-Oct-19 12:20 (EDT)
+// Function: test file watching
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prog.conf")
+	other := filepath.Join(dir, "other.conf")
+	os.WriteFile(file, []byte("a"), 0644)
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	changes, err := watchFiles([]string{file}, 50*time.Millisecond, quit)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	// unwatched files are ignored
+	os.WriteFile(other, []byte("b"), 0644)
+	select {
+	case name := <-changes:
+		t.Fatalf("unexpected change: %s", name)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// replace it, the way a deploy would
+	tmp := file + ".tmp"
+	os.WriteFile(tmp, []byte("new contents"), 0644)
+	os.Rename(tmp, file)
+
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("change not detected")
+	}
+
+	// removing it is not a change, until it comes back
+	os.Remove(file)
+	select {
+	case name := <-changes:
+		t.Fatalf("unexpected change on remove: %s", name)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	os.WriteFile(file, []byte("back"), 0644)
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("change not detected")
+	}
+}